@@ -0,0 +1,339 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"habittracker/auth"
+	"habittracker/habits"
+	"habittracker/logging"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rango por defecto y máximo para consultas por fecha
+const (
+	defaultRangeDays = 30
+	maxRangeDays     = 3660
+)
+
+type contextKey struct{}
+
+// Server expone la API REST de hábitos y logs
+type Server struct {
+	habitManager *habits.HabitManager
+	tokens       *auth.TokenStore
+	users        *auth.UserStore
+	loc          *time.Location
+	mux          *http.ServeMux
+}
+
+// NewServer crea el servidor de la API. El rango de fechas por defecto termina
+// hoy en la zona horaria loc.
+func NewServer(habitManager *habits.HabitManager, tokens *auth.TokenStore, loc *time.Location) *Server {
+	s := &Server{
+		habitManager: habitManager,
+		tokens:       tokens,
+		loc:          loc,
+		mux:          http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/habits", s.listHabits)
+	s.mux.HandleFunc("POST /api/habits", s.createHabit)
+	s.mux.HandleFunc("GET /api/habits/{id}", s.getHabit)
+	s.mux.HandleFunc("PUT /api/habits/{id}", s.updateHabit)
+	s.mux.HandleFunc("DELETE /api/habits/{id}", s.deleteHabit)
+	s.mux.HandleFunc("GET /api/habits/{id}/stats", s.getHabitStats)
+	s.mux.HandleFunc("GET /api/logs", s.listLogs)
+	s.mux.HandleFunc("PUT /api/logs", s.upsertLog)
+	s.mux.HandleFunc("GET /api/stats", s.listStats)
+
+	return s
+}
+
+// SetUsers limita la API a los dueños de los hábitos (administradores y lista
+// de la configuración): se rechazan los tokens de los usuarios aprobados con
+// /approve y los de los bloqueados con /ban después de emitir el token
+func (s *Server) SetUsers(users *auth.UserStore) {
+	s.users = users
+}
+
+// Handler devuelve el handler HTTP autenticado de la API (montar en /api/)
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		userID, err := s.tokens.Authenticate(token)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if s.users != nil && !s.users.OwnsHabits(userID) {
+			writeError(w, http.StatusForbidden, "user does not own the habits")
+			return
+		}
+
+		logger := slog.Default().With("user_id", userID, "method", r.Method, "path", r.URL.Path)
+		ctx := logging.WithLogger(r.Context(), logger)
+		ctx = context.WithValue(ctx, contextKey{}, userID)
+		s.mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UserID devuelve el usuario autenticado de la petición
+func UserID(ctx context.Context) int64 {
+	userID, _ := ctx.Value(contextKey{}).(int64)
+	return userID
+}
+
+// habitRequest es el cuerpo para crear o editar un hábito
+type habitRequest struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Weekdays     []time.Weekday `json:"weekdays"`
+	ReminderTime string         `json:"reminder_time"`
+}
+
+func (s *Server) listHabits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.habitManager.GetHabits())
+}
+
+func (s *Server) createHabit(w http.ResponseWriter, r *http.Request) {
+	var req habitRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := habits.ValidateSchedule(req.Weekdays, req.ReminderTime); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	habit, err := s.habitManager.AddHabit(strings.TrimSpace(req.Name), req.Description)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	if len(req.Weekdays) > 0 || req.ReminderTime != "" {
+		if habit, err = s.habitManager.SetSchedule(habit.ID, req.Weekdays, req.ReminderTime); err != nil {
+			s.internalError(w, r, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, habit)
+}
+
+func (s *Server) getHabit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	habit, err := s.habitManager.GetHabit(id)
+	if err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, habit)
+}
+
+func (s *Server) updateHabit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req habitRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := habits.ValidateSchedule(req.Weekdays, req.ReminderTime); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	habit, err := s.habitManager.UpdateHabit(id, strings.TrimSpace(req.Name), req.Description)
+	if err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	if habit, err = s.habitManager.SetSchedule(id, req.Weekdays, req.ReminderTime); err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, habit)
+}
+
+func (s *Server) deleteHabit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.habitManager.DeleteHabit(id); err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getHabitStats(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := s.dateRange(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := s.habitManager.GetStats(id, from, to)
+	if err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) listLogs(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := s.dateRange(w, r)
+	if !ok {
+		return
+	}
+
+	habitID := 0
+	if v := r.URL.Query().Get("habit_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "habit_id must be a number")
+			return
+		}
+		habitID = id
+	}
+
+	writeJSON(w, http.StatusOK, s.habitManager.GetDailyLogs(from, to, habitID))
+}
+
+func (s *Server) upsertLog(w http.ResponseWriter, r *http.Request) {
+	var entry habits.DailyLog
+	if !decodeBody(w, r, &entry) {
+		return
+	}
+	if _, err := time.Parse(habits.DateFormat, entry.Date); err != nil {
+		writeError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+
+	if err := s.habitManager.UpsertDailyLog(entry); err != nil {
+		s.habitError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) listStats(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := s.dateRange(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := s.habitManager.GetAllStats(from, to)
+	if err != nil {
+		s.internalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// habitError traduce errores de HabitManager a respuestas HTTP
+func (s *Server) habitError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, habits.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.internalError(w, r, err)
+}
+
+func (s *Server) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("API request failed", "error", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+// pathID lee el parámetro {id} de la ruta
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id must be a number")
+		return 0, false
+	}
+	return id, true
+}
+
+// dateRange lee from y to de la query; por defecto los últimos 30 días
+func (s *Server) dateRange(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	now := time.Now().In(s.loc)
+	to := now.Format(habits.DateFormat)
+	from := now.AddDate(0, 0, -(defaultRangeDays - 1)).Format(habits.DateFormat)
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		from = v
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to = v
+	}
+
+	fromDate, err := time.Parse(habits.DateFormat, from)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "from must be YYYY-MM-DD")
+		return "", "", false
+	}
+	toDate, err := time.Parse(habits.DateFormat, to)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "to must be YYYY-MM-DD")
+		return "", "", false
+	}
+	if toDate.Before(fromDate) {
+		writeError(w, http.StatusBadRequest, "from must not be after to")
+		return "", "", false
+	}
+	if toDate.Sub(fromDate) > maxRangeDays*24*time.Hour {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("date range must not exceed %d days", maxRangeDays))
+		return "", "", false
+	}
+
+	return from, to, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"habittracker/auth"
+	"habittracker/habits"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	tokens, err := auth.NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+	if err != nil {
+		t.Fatalf("Failed to create token store: %v", err)
+	}
+	token, err := tokens.Issue(42)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	return NewServer(hm, tokens, time.Local).Handler(), token
+}
+
+func doRequest(t *testing.T, h http.Handler, token, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestAPIRequiresToken prueba que las peticiones sin token válido se rechazan
+func TestAPIRequiresToken(t *testing.T) {
+	h, _ := newTestServer(t)
+
+	if rec := doRequest(t, h, "", "GET", "/api/habits", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+	if rec := doRequest(t, h, "ht_bogus", "GET", "/api/habits", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with invalid token, got %d", rec.Code)
+	}
+}
+
+// TestAPIRejectsUnapprovedUsers prueba que solo los dueños de los hábitos usan
+// la API: se rechazan los invitados aprobados con /approve y los bloqueados
+func TestAPIRejectsUnapprovedUsers(t *testing.T) {
+	dir := t.TempDir()
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	tokens, _ := auth.NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+	users, err := auth.NewUserStore(filepath.Join(dir, "users.json"), []int64{7}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create user store: %v", err)
+	}
+	s := NewServer(hm, tokens, time.Local)
+	s.SetUsers(users)
+	h := s.Handler()
+
+	owner, _ := tokens.Issue(7)
+	other, _ := tokens.Issue(42)
+	if rec := doRequest(t, h, owner, "GET", "/api/habits", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a configured user, got %d", rec.Code)
+	}
+	if rec := doRequest(t, h, other, "GET", "/api/habits", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an unapproved user, got %d", rec.Code)
+	}
+
+	users.Request(auth.User{ID: 42})
+	users.Approve(42)
+	if rec := doRequest(t, h, other, "DELETE", "/api/habits/1", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a guest approved with /approve, got %d", rec.Code)
+	}
+}
+
+// TestAPIHabitCRUD prueba el ciclo completo de un hábito
+func TestAPIHabitCRUD(t *testing.T) {
+	h, token := newTestServer(t)
+
+	rec := doRequest(t, h, token, "POST", "/api/habits", map[string]string{"name": "Leer"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var created habits.Habit
+	json.NewDecoder(rec.Body).Decode(&created)
+
+	rec = doRequest(t, h, token, "PUT", "/api/habits/1", map[string]string{"name": "Leer 20 páginas"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 on update, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(t, h, token, "GET", "/api/habits/1", nil)
+	var got habits.Habit
+	json.NewDecoder(rec.Body).Decode(&got)
+	if got.Name != "Leer 20 páginas" {
+		t.Errorf("Expected updated name, got %q", got.Name)
+	}
+
+	if rec := doRequest(t, h, token, "DELETE", "/api/habits/1", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on delete, got %d", rec.Code)
+	}
+	if rec := doRequest(t, h, token, "GET", "/api/habits/1", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+// TestAPILogsAndStats prueba el upsert de logs por fecha y las estadísticas
+func TestAPILogsAndStats(t *testing.T) {
+	h, token := newTestServer(t)
+	doRequest(t, h, token, "POST", "/api/habits", map[string]string{"name": "Meditar"})
+
+	for _, date := range []string{"2030-01-01", "2030-01-02", "2030-01-03"} {
+		entry := habits.DailyLog{Date: date, HabitID: 1, Planned: true, Completed: date != "2030-01-02"}
+		if rec := doRequest(t, h, token, "PUT", "/api/logs", entry); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 on upsert, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	// Reemplazar un log existente
+	doRequest(t, h, token, "PUT", "/api/logs", habits.DailyLog{Date: "2030-01-02", HabitID: 1, Planned: true, Completed: true})
+
+	rec := doRequest(t, h, token, "GET", "/api/logs?from=2030-01-01&to=2030-01-02", nil)
+	var logs []habits.DailyLog
+	json.NewDecoder(rec.Body).Decode(&logs)
+	if len(logs) != 2 || !logs[1].Completed {
+		t.Fatalf("Unexpected logs: %+v", logs)
+	}
+
+	rec = doRequest(t, h, token, "GET", "/api/habits/1/stats?from=2030-01-01&to=2030-01-03", nil)
+	var stats habits.HabitStats
+	json.NewDecoder(rec.Body).Decode(&stats)
+	if stats.DaysCompleted != 3 || stats.CurrentStreak != 3 || stats.BestStreak != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if rec := doRequest(t, h, token, "PUT", "/api/logs", habits.DailyLog{Date: "2030-01-01", HabitID: 99}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown habit, got %d", rec.Code)
+	}
+	if rec := doRequest(t, h, token, "GET", "/api/logs?from=2030-02-01&to=2030-01-01", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for inverted range, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Duración por defecto de los códigos de login y las sesiones
+const (
+	DefaultLoginCodeTTL = 10 * time.Minute
+	DefaultSessionTTL   = 7 * 24 * time.Hour
+)
+
+// ErrInvalidSession se devuelve cuando un código o sesión no existe o expiró
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+type grant struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+// SessionStore emite códigos de login de un solo uso y sesiones web.
+// Se guarda en memoria: al reiniciar hay que pedir un nuevo enlace.
+type SessionStore struct {
+	codes      map[string]grant
+	sessions   map[string]grant
+	LoginTTL   time.Duration
+	SessionTTL time.Duration
+	mu         sync.Mutex
+}
+
+// NewSessionStore crea un almacén de sesiones vacío
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		codes:      make(map[string]grant),
+		sessions:   make(map[string]grant),
+		LoginTTL:   DefaultLoginCodeTTL,
+		SessionTTL: DefaultSessionTTL,
+	}
+}
+
+// IssueLoginCode genera un código de un solo uso para el usuario
+func (ss *SessionStore) IssueLoginCode(userID int64) (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.purge(time.Now())
+	ss.codes[code] = grant{userID: userID, expiresAt: time.Now().Add(ss.LoginTTL)}
+	return code, nil
+}
+
+// Redeem canjea un código de login por una sesión. El código deja de ser válido.
+func (ss *SessionStore) Redeem(code string) (string, int64, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	g, ok := ss.codes[code]
+	delete(ss.codes, code)
+	if !ok || time.Now().After(g.expiresAt) {
+		return "", 0, ErrInvalidSession
+	}
+
+	session, err := randomToken()
+	if err != nil {
+		return "", 0, err
+	}
+	ss.sessions[session] = grant{userID: g.userID, expiresAt: time.Now().Add(ss.SessionTTL)}
+	return session, g.userID, nil
+}
+
+// Validate devuelve el usuario de una sesión vigente
+func (ss *SessionStore) Validate(session string) (int64, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	g, ok := ss.sessions[session]
+	if !ok || time.Now().After(g.expiresAt) {
+		delete(ss.sessions, session)
+		return 0, ErrInvalidSession
+	}
+	return g.userID, nil
+}
+
+// End cierra una sesión
+func (ss *SessionStore) End(session string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.sessions, session)
+}
+
+// EndUser cierra todas las sesiones y anula los códigos de login de un
+// usuario. Devuelve cuántas sesiones se cerraron.
+func (ss *SessionStore) EndUser(userID int64) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for k, g := range ss.codes {
+		if g.userID == userID {
+			delete(ss.codes, k)
+		}
+	}
+	ended := 0
+	for k, g := range ss.sessions {
+		if g.userID == userID {
+			delete(ss.sessions, k)
+			ended++
+		}
+	}
+	return ended
+}
+
+// purge elimina códigos y sesiones vencidos
+func (ss *SessionStore) purge(now time.Time) {
+	for k, g := range ss.codes {
+		if now.After(g.expiresAt) {
+			delete(ss.codes, k)
+		}
+	}
+	for k, g := range ss.sessions {
+		if now.After(g.expiresAt) {
+			delete(ss.sessions, k)
+		}
+	}
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"habittracker/storage"
+	"os"
+	"sync"
+	"time"
+)
+
+// tokenPrefix identifica los tokens de la API en logs y gestores de secretos
+const tokenPrefix = "ht_"
+
+// ErrInvalidToken se devuelve cuando un token no existe o fue revocado
+var ErrInvalidToken = errors.New("invalid API token")
+
+// APIToken es un token de la API emitido a un usuario de Telegram.
+// Solo se guarda el hash SHA-256; el token en claro se muestra una única vez.
+type APIToken struct {
+	Hash       string    `json:"hash"`
+	UserID     int64     `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+}
+
+// TokenStore guarda los tokens de la API en un archivo JSON
+type TokenStore struct {
+	tokens []APIToken
+	file   string
+	mu     sync.RWMutex
+}
+
+// NewTokenStore crea el almacén y carga los tokens existentes
+func NewTokenStore(file string) (*TokenStore, error) {
+	ts := &TokenStore{
+		tokens: []APIToken{},
+		file:   file,
+	}
+	if err := ts.load(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Issue genera un nuevo token para el usuario y devuelve el token en claro
+func (ts *TokenStore) Issue(userID int64) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(buf)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.tokens = append(ts.tokens, APIToken{
+		Hash:      hashToken(token),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	})
+	if err := ts.save(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate devuelve el usuario dueño del token
+func (ts *TokenStore) Authenticate(token string) (int64, error) {
+	hash := hashToken(token)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for i := range ts.tokens {
+		if subtle.ConstantTimeCompare([]byte(ts.tokens[i].Hash), []byte(hash)) == 1 {
+			ts.tokens[i].LastUsedAt = time.Now()
+			return ts.tokens[i].UserID, nil
+		}
+	}
+	return 0, ErrInvalidToken
+}
+
+// Revoke elimina todos los tokens de un usuario y devuelve cuántos se eliminaron
+func (ts *TokenStore) Revoke(userID int64) (int, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	kept := ts.tokens[:0]
+	removed := 0
+	for _, t := range ts.tokens {
+		if t.UserID == userID {
+			removed++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	ts.tokens = kept
+
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, ts.save()
+}
+
+// Count devuelve la cantidad de tokens activos de un usuario
+func (ts *TokenStore) Count(userID int64) int {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	n := 0
+	for _, t := range ts.tokens {
+		if t.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// load carga los tokens desde el archivo
+func (ts *TokenStore) load() error {
+	data, err := storage.ReadFile(ts.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &ts.tokens)
+}
+
+// save guarda los tokens en el archivo (solo legible por el dueño)
+func (ts *TokenStore) save() error {
+	data, err := json.MarshalIndent(ts.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(ts.file, data, 0600)
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"habittracker/storage"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UserStatus es el estado de acceso de un usuario de Telegram
+type UserStatus string
+
+const (
+	StatusUnknown  UserStatus = ""
+	StatusPending  UserStatus = "pending"
+	StatusApproved UserStatus = "approved"
+	StatusBanned   UserStatus = "banned"
+)
+
+// ErrUserNotFound se devuelve al aprobar o bloquear un usuario que nunca escribió al bot
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrProtectedUser se devuelve al intentar bloquear a un administrador o a un
+// usuario de la lista de la configuración
+var ErrProtectedUser = errors.New("user is configured as admin or allowed")
+
+// User es un usuario que pidió acceso al bot
+type User struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	Status      UserStatus `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+// UserStore controla quién puede usar el bot: los administradores y la lista de
+// la configuración tienen acceso siempre; el resto pide acceso y un
+// administrador lo aprueba o lo bloquea. Las decisiones se guardan en un archivo JSON.
+type UserStore struct {
+	users   []User
+	allowed map[int64]bool
+	admins  map[int64]bool
+	file    string
+	mu      sync.RWMutex
+}
+
+// NewUserStore crea el almacén con los usuarios permitidos y los administradores
+// de la configuración, y carga las decisiones existentes
+func NewUserStore(file string, allowed, admins []int64) (*UserStore, error) {
+	us := &UserStore{
+		users:   []User{},
+		allowed: make(map[int64]bool),
+		admins:  make(map[int64]bool),
+		file:    file,
+	}
+	for _, id := range allowed {
+		us.allowed[id] = true
+	}
+	for _, id := range admins {
+		us.admins[id] = true
+	}
+	if err := us.load(); err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// Status devuelve el estado de acceso de un usuario
+func (us *UserStore) Status(id int64) UserStatus {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	if us.admins[id] || us.allowed[id] {
+		return StatusApproved
+	}
+	if u := us.find(id); u != nil {
+		return u.Status
+	}
+	return StatusUnknown
+}
+
+// IsAdmin indica si el usuario es administrador
+func (us *UserStore) IsAdmin(id int64) bool {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	return us.admins[id]
+}
+
+// OwnsHabits indica si el usuario comparte los hábitos del bot: los
+// administradores y la lista de la configuración. Los usuarios aprobados con
+// /approve usan el bot pero no ven ni modifican los hábitos.
+func (us *UserStore) OwnsHabits(id int64) bool {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	return us.admins[id] || us.allowed[id]
+}
+
+// Admins devuelve los IDs de los administradores
+func (us *UserStore) Admins() []int64 {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	ids := make([]int64, 0, len(us.admins))
+	for id := range us.admins {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Owners devuelve los IDs de los dueños de los hábitos (administradores y lista
+// de la configuración)
+func (us *UserStore) Owners() []int64 {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	ids := make([]int64, 0, len(us.admins)+len(us.allowed))
+	for id := range us.admins {
+		ids = append(ids, id)
+	}
+	for id := range us.allowed {
+		if !us.admins[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Request registra una solicitud de acceso. Devuelve true si la solicitud es
+// nueva, para avisar a los administradores una sola vez.
+func (us *UserStore) Request(user User) (bool, error) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if us.admins[user.ID] || us.allowed[user.ID] || us.find(user.ID) != nil {
+		return false, nil
+	}
+
+	user.Status = StatusPending
+	user.RequestedAt = time.Now()
+	us.users = append(us.users, user)
+	return true, us.save()
+}
+
+// Approve da acceso a un usuario que lo pidió (o que estaba bloqueado)
+func (us *UserStore) Approve(id int64) (*User, error) {
+	return us.setStatus(id, StatusApproved)
+}
+
+// Ban bloquea a un usuario: sus mensajes se ignoran
+func (us *UserStore) Ban(id int64) (*User, error) {
+	us.mu.RLock()
+	protected := us.admins[id] || us.allowed[id]
+	us.mu.RUnlock()
+	if protected {
+		return nil, ErrProtectedUser
+	}
+	return us.setStatus(id, StatusBanned)
+}
+
+// List devuelve los usuarios que pidieron acceso, primero los pendientes
+func (us *UserStore) List() []User {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	users := make([]User, len(us.users))
+	copy(users, us.users)
+	sort.SliceStable(users, func(i, j int) bool {
+		if (users[i].Status == StatusPending) != (users[j].Status == StatusPending) {
+			return users[i].Status == StatusPending
+		}
+		return users[i].RequestedAt.Before(users[j].RequestedAt)
+	})
+	return users
+}
+
+func (us *UserStore) setStatus(id int64, status UserStatus) (*User, error) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	u := us.find(id)
+	if u == nil {
+		return nil, fmt.Errorf("user %d: %w", id, ErrUserNotFound)
+	}
+	u.Status = status
+	u.UpdatedAt = time.Now()
+	if err := us.save(); err != nil {
+		return nil, err
+	}
+	user := *u
+	return &user, nil
+}
+
+func (us *UserStore) find(id int64) *User {
+	for i := range us.users {
+		if us.users[i].ID == id {
+			return &us.users[i]
+		}
+	}
+	return nil
+}
+
+// load carga los usuarios desde el archivo
+func (us *UserStore) load() error {
+	data, err := storage.ReadFile(us.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &us.users)
+}
+
+// save guarda los usuarios en el archivo
+func (us *UserStore) save() error {
+	data, err := json.MarshalIndent(us.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(us.file, data, 0600)
+}
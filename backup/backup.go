@@ -0,0 +1,371 @@
+// Package backup crea copias comprimidas del directorio de datos, aplica la
+// política de retención y las restaura después de verificarlas.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"habittracker/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Nombres de los archivos de backup: habittracker-20240305-030000.tar.gz
+const (
+	filePrefix      = "habittracker-"
+	fileSuffix      = ".tar.gz"
+	timestampFormat = "20060102-150405"
+	manifestName    = "MANIFEST.json"
+)
+
+// defaultFileMode son los permisos de los archivos restaurados sin permisos en
+// el backup
+const defaultFileMode os.FileMode = 0600
+
+// ErrCorrupt se devuelve cuando un backup no coincide con su manifiesto
+var ErrCorrupt = errors.New("corrupt backup")
+
+// Manifest describe el contenido de un backup
+type Manifest struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Files     map[string]string `json:"files"` // nombre → SHA-256
+}
+
+// Info es un backup existente en el directorio de backups
+type Info struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Retention indica cuántos backups se conservan: el más reciente de cada uno de
+// los últimos Daily días, Weekly semanas y Monthly meses
+type Retention struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// Manager crea y poda los backups de un directorio de datos
+type Manager struct {
+	DataDir   string
+	Dir       string
+	Retention Retention
+}
+
+// NewManager crea el gestor de backups
+func NewManager(dataDir, dir string, retention Retention) *Manager {
+	return &Manager{DataDir: dataDir, Dir: dir, Retention: retention}
+}
+
+// Run crea un backup y aplica la retención. Devuelve el backup creado y los eliminados.
+func (m *Manager) Run(now time.Time) (string, []string, error) {
+	path, err := m.Create(now)
+	if err != nil {
+		return "", nil, err
+	}
+	removed, err := m.Prune(now)
+	return path, removed, err
+}
+
+// Create comprime los archivos del directorio de datos en un backup nuevo
+func (m *Manager) Create(now time.Time) (string, error) {
+	if err := os.MkdirAll(m.Dir, 0700); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(m.DataDir)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(m.Dir, filePrefix+now.Format(timestampFormat)+fileSuffix)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	manifest := Manifest{CreatedAt: now, Files: make(map[string]string)}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			f.Close()
+			return "", err
+		}
+		data, err := os.ReadFile(filepath.Join(m.DataDir, entry.Name()))
+		if err != nil {
+			f.Close()
+			return "", err
+		}
+		if err := writeTarFile(tw, entry.Name(), data, info.Mode().Perm(), now); err != nil {
+			f.Close()
+			return "", err
+		}
+		manifest.Files[entry.Name()] = checksum(data)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := errors.Join(
+		writeTarFile(tw, manifestName, manifestData, defaultFileMode, now),
+		tw.Close(),
+		gz.Close(),
+		f.Sync(),
+		f.Close(),
+	); err != nil {
+		return "", err
+	}
+
+	return path, os.Rename(tmp, path)
+}
+
+// List devuelve los backups del directorio, del más reciente al más antiguo
+func (m *Manager) List() ([]Info, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var backups []Info
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+		created, err := time.ParseInLocation(timestampFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Info{Path: filepath.Join(m.Dir, name), CreatedAt: created, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Prune elimina los backups que no conserva la política de retención.
+// El backup más reciente se conserva siempre.
+func (m *Manager) Prune(now time.Time) ([]string, error) {
+	backups, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	if len(backups) > 0 {
+		keep[backups[0].Path] = true
+	}
+
+	// backups está ordenado del más reciente al más antiguo: el primero de cada período es el que se conserva
+	periods := []struct {
+		limit int
+		key   func(time.Time) string
+		start time.Time
+	}{
+		{m.Retention.Daily, func(t time.Time) string { return t.Format("2006-01-02") }, now.AddDate(0, 0, -m.Retention.Daily)},
+		{m.Retention.Weekly, func(t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-W%02d", y, w) }, now.AddDate(0, 0, -7*m.Retention.Weekly)},
+		{m.Retention.Monthly, func(t time.Time) string { return t.Format("2006-01") }, now.AddDate(0, -m.Retention.Monthly, 0)},
+	}
+	for _, p := range periods {
+		seen := make(map[string]bool)
+		for _, b := range backups {
+			if len(seen) >= p.limit || !b.CreatedAt.After(p.start) {
+				break
+			}
+			key := p.key(b.CreatedAt)
+			if !seen[key] {
+				seen[key] = true
+				keep[b.Path] = true
+			}
+		}
+	}
+
+	var removed []string
+	for _, b := range backups {
+		if keep[b.Path] {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b.Path)
+	}
+	return removed, nil
+}
+
+// Verify lee un backup completo y comprueba los archivos contra el manifiesto
+func Verify(path string) (*Manifest, error) {
+	_, manifest, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Restore verifica un backup y reemplaza el directorio de datos con su contenido.
+// check (opcional) valida los datos extraídos antes del reemplazo. El directorio
+// anterior se conserva como dataDir.pre-restore-<fecha>, cuya ruta se devuelve.
+func Restore(path, dataDir string, check func(dir string) error) (string, error) {
+	files, _, err := read(path)
+	if err != nil {
+		return "", err
+	}
+
+	dataDir = filepath.Clean(dataDir)
+	tmp, err := os.MkdirTemp(filepath.Dir(dataDir), filepath.Base(dataDir)+".restore-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	for name, file := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), file.data, file.mode); err != nil {
+			return "", err
+		}
+	}
+	if check != nil {
+		if err := check(tmp); err != nil {
+			return "", fmt.Errorf("restored data failed validation: %w", err)
+		}
+	}
+
+	previous := ""
+	if _, err := os.Stat(dataDir); err == nil {
+		previous = dataDir + ".pre-restore-" + time.Now().Format(timestampFormat)
+		if err := os.Rename(dataDir, previous); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(tmp, dataDir); err != nil {
+		if previous != "" {
+			os.Rename(previous, dataDir)
+		}
+		return "", err
+	}
+	return previous, nil
+}
+
+// archivedFile es un archivo extraído de un backup
+type archivedFile struct {
+	data []byte
+	mode os.FileMode
+}
+
+// read extrae el contenido de un backup en memoria y lo verifica contra el manifiesto
+func read(path string) (map[string]archivedFile, *Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]archivedFile)
+	var manifest *Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Name != filepath.Base(hdr.Name) || strings.HasPrefix(hdr.Name, ".") {
+			return nil, nil, fmt.Errorf("%w: unexpected entry %q", ErrCorrupt, hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+
+		if hdr.Name == manifestName {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("%w: invalid manifest: %v", ErrCorrupt, err)
+			}
+			continue
+		}
+		mode := hdr.FileInfo().Mode().Perm()
+		if mode == 0 {
+			mode = defaultFileMode
+		}
+		files[hdr.Name] = archivedFile{data: data, mode: mode}
+	}
+
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("%w: missing %s", ErrCorrupt, manifestName)
+	}
+	if len(files) != len(manifest.Files) {
+		return nil, nil, fmt.Errorf("%w: expected %d files, found %d", ErrCorrupt, len(manifest.Files), len(files))
+	}
+	for name, sum := range manifest.Files {
+		file, ok := files[name]
+		data := file.data
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: missing %s", ErrCorrupt, name)
+		}
+		if checksum(data) != sum {
+			return nil, nil, fmt.Errorf("%w: checksum mismatch for %s", ErrCorrupt, name)
+		}
+		// Los archivos cifrados se validan al restaurarlos, con la clave
+		if strings.HasSuffix(name, ".json") && len(data) > 0 && !storage.Encrypted(data) && !json.Valid(data) {
+			return nil, nil, fmt.Errorf("%w: %s is not valid JSON", ErrCorrupt, name)
+		}
+	}
+	return files, manifest, nil
+}
+
+// writeTarFile agrega un archivo al backup con sus permisos, para restaurarlo
+// igual (los datos sensibles se guardan como 0600)
+func writeTarFile(tw *tar.Writer, name string, data []byte, mode os.FileMode, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    int64(mode),
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
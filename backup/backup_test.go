@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeDataDir(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// TestCreateVerifyRestore prueba el ciclo completo de un backup
+func TestCreateVerifyRestore(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	writeDataDir(t, dataDir, map[string]string{
+		"habits.json":     `[{"id": 1, "name": "Leer"}]`,
+		"daily_logs.json": `[]`,
+	})
+	os.Chmod(filepath.Join(dataDir, "habits.json"), 0600)
+
+	m := NewManager(dataDir, filepath.Join(root, "backups"), Retention{Daily: 7})
+	path, err := m.Create(time.Date(2024, 3, 5, 3, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if filepath.Base(path) != "habittracker-20240305-030000.tar.gz" {
+		t.Errorf("Unexpected backup name %s", path)
+	}
+
+	manifest, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Errorf("Expected 2 files in manifest, got %v", manifest.Files)
+	}
+
+	// Los datos cambian después del backup; restaurar vuelve al estado guardado
+	writeDataDir(t, dataDir, map[string]string{"habits.json": `[]`, "extra.json": `{}`})
+
+	if _, err := Restore(path, dataDir, func(string) error { return errors.New("nope") }); err == nil {
+		t.Fatal("Expected restore to fail when the check fails")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dataDir, "habits.json")); string(data) != `[]` {
+		t.Fatal("A failed restore must not touch the data directory")
+	}
+
+	previous, err := Restore(path, dataDir, nil)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dataDir, "habits.json")); string(data) != `[{"id": 1, "name": "Leer"}]` {
+		t.Errorf("Unexpected restored habits: %s", data)
+	}
+	// Los permisos se restauran como estaban
+	for name, want := range map[string]os.FileMode{"habits.json": 0600, "daily_logs.json": 0644} {
+		info, err := os.Stat(filepath.Join(dataDir, name))
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("Expected %s to be restored with mode %v, got %v", name, want, info.Mode().Perm())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "extra.json")); !os.IsNotExist(err) {
+		t.Error("Files not in the backup must not remain after restore")
+	}
+	if _, err := os.Stat(filepath.Join(previous, "extra.json")); err != nil {
+		t.Errorf("Expected previous data to be kept in %s", previous)
+	}
+
+	// Un backup truncado no se verifica
+	data, _ := os.ReadFile(path)
+	corrupt := filepath.Join(root, "corrupt.tar.gz")
+	os.WriteFile(corrupt, data[:len(data)/2], 0600)
+	if _, err := Verify(corrupt); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+// TestPrune prueba la retención diaria, semanal y mensual
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	writeDataDir(t, dataDir, map[string]string{"habits.json": `[]`})
+
+	m := NewManager(dataDir, filepath.Join(root, "backups"), Retention{Daily: 3, Weekly: 2, Monthly: 2})
+
+	// Dos backups por día durante 90 días
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.Local)
+	for d := 89; d >= 0; d-- {
+		for _, hour := range []int{3, 15} {
+			at := time.Date(2024, 6, 30, hour, 0, 0, 0, time.Local).AddDate(0, 0, -d)
+			if at.After(now) {
+				continue
+			}
+			if _, err := m.Create(at); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+	}
+
+	if _, err := m.Prune(now); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	backups, err := m.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var kept []string
+	for _, b := range backups {
+		kept = append(kept, b.CreatedAt.Format("2006-01-02 15"))
+	}
+
+	// Diarios: 30, 29, 28 de junio; semanales: el último de la semana 26 (30/6) y de la 25 (23/6);
+	// mensuales: el último de junio (30/6) y de mayo (31/5)
+	want := []string{"2024-06-30 03", "2024-06-29 15", "2024-06-28 15", "2024-06-23 15", "2024-05-31 15"}
+	if len(kept) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, kept)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, kept)
+			break
+		}
+	}
+}
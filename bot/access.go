@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"habittracker/auth"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SetAccess habilita el modo privado: solo los usuarios aprobados usan el bot
+func (b *Bot) SetAccess(users *auth.UserStore) {
+	b.users = users
+}
+
+// authorize decide si un update se despacha. A los usuarios desconocidos les
+// registra la solicitud de acceso y avisa a los administradores.
+func (b *Bot) authorize(ctx context.Context, update tgbotapi.Update) bool {
+	if b.users == nil {
+		return true
+	}
+
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+	from := update.SentFrom()
+	if from == nil {
+		return false
+	}
+
+	status := b.users.Status(from.ID)
+	if status == auth.StatusApproved {
+		return true
+	}
+	logger.Warn("Update from unauthorized user", "user_id", from.ID, "status", status)
+
+	if update.CallbackQuery != nil {
+		b.request(tgbotapi.NewCallback(update.CallbackQuery.ID, tr.T("access.no_access")))
+		return false
+	}
+	if update.Message == nil || update.Message.Chat.Type != "private" {
+		return false
+	}
+
+	chatID := update.Message.Chat.ID
+	switch status {
+	case auth.StatusBanned:
+		// Los usuarios bloqueados no reciben respuesta
+	case auth.StatusPending:
+		b.send(tgbotapi.NewMessage(chatID, tr.T("access.pending")))
+	default:
+		created, err := b.users.Request(auth.User{
+			ID:       from.ID,
+			Name:     strings.TrimSpace(from.FirstName + " " + from.LastName),
+			Username: from.UserName,
+		})
+		if err != nil {
+			logger.Error("Error saving access request", "user_id", from.ID, "error", err)
+			return false
+		}
+		b.send(tgbotapi.NewMessage(chatID, tr.T("access.requested")))
+		if created {
+			logger.Info("Access requested", "user_id", from.ID)
+			for _, id := range b.users.Admins() {
+				admin := b.userLocalizer(id)
+				b.send(tgbotapi.NewMessage(id, admin.T("access.new_request",
+					displayName(admin, from.FirstName, from.UserName), from.ID, from.ID, from.ID)))
+			}
+		}
+	}
+	return false
+}
+
+// requireAdmin responde y devuelve false si el comando no lo envía un administrador
+func (b *Bot) requireAdmin(ctx context.Context, message *tgbotapi.Message) bool {
+	tr := i18n.FromContext(ctx)
+	if b.users == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.disabled")))
+		return false
+	}
+	if !b.isAdmin(senderID(message), message.Chat.ID) {
+		logging.FromContext(ctx).Warn("Admin command from a non-admin user", "command", message.Command())
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.admin_only")))
+		return false
+	}
+	return true
+}
+
+// ownsHabits indica si el usuario puede ver y modificar los hábitos. Sin modo
+// privado, cualquiera; en modo privado, los administradores y la lista de la
+// configuración, no los usuarios aprobados con /approve.
+func (b *Bot) ownsHabits(userID int64) bool {
+	return b.users == nil || b.users.OwnsHabits(userID)
+}
+
+// requireOwner responde y devuelve false si el mensaje no lo envía un dueño de los hábitos
+func (b *Bot) requireOwner(ctx context.Context, message *tgbotapi.Message) bool {
+	if b.ownsHabits(senderID(message)) {
+		return true
+	}
+	logging.FromContext(ctx).Warn("Habit command from a user who does not own the habits", "command", message.Command())
+	b.send(tgbotapi.NewMessage(message.Chat.ID, i18n.FromContext(ctx).T("access.owner_only")))
+	return false
+}
+
+// senderID devuelve el usuario que envió un mensaje, o 0 si no tiene remitente
+func senderID(message *tgbotapi.Message) int64 {
+	if message.From == nil {
+		return 0
+	}
+	return message.From.ID
+}
+
+// handleUsers maneja el comando /users: lista las solicitudes de acceso
+func (b *Bot) handleUsers(ctx context.Context, message *tgbotapi.Message) {
+	if !b.requireAdmin(ctx, message) {
+		return
+	}
+
+	tr := i18n.FromContext(ctx)
+	users := b.users.List()
+	if len(users) == 0 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("users.empty")))
+		return
+	}
+
+	icons := map[auth.UserStatus]string{
+		auth.StatusPending:  "⏳",
+		auth.StatusApproved: "✅",
+		auth.StatusBanned:   "🚫",
+	}
+	text := tr.N("users.title", len(users)) + "\n\n"
+	for _, u := range users {
+		text += fmt.Sprintf("%s %s (ID %d) - %s\n", icons[u.Status], displayName(tr, u.Name, u.Username), u.ID, tr.T("users.status."+string(u.Status)))
+	}
+	text += "\n" + tr.T("users.footer")
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// handleApprove maneja el comando /approve <id>
+func (b *Bot) handleApprove(ctx context.Context, message *tgbotapi.Message) {
+	b.changeAccess(ctx, message, true)
+}
+
+// handleBan maneja el comando /ban <id>
+func (b *Bot) handleBan(ctx context.Context, message *tgbotapi.Message) {
+	b.changeAccess(ctx, message, false)
+}
+
+func (b *Bot) changeAccess(ctx context.Context, message *tgbotapi.Message, approve bool) {
+	if !b.requireAdmin(ctx, message) {
+		return
+	}
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	id, err := strconv.ParseInt(strings.TrimSpace(message.CommandArguments()), 10, 64)
+	if err != nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.usage", message.Command())))
+		return
+	}
+
+	var user *auth.User
+	if approve {
+		user, err = b.users.Approve(id)
+	} else {
+		user, err = b.users.Ban(id)
+	}
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.not_found", id)))
+		return
+	case errors.Is(err, auth.ErrProtectedUser):
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.protected")))
+		return
+	case err != nil:
+		logger.Error("Error updating user access", "user_id", id, "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.save_error")))
+		return
+	}
+	logger.Info("User access changed", "user_id", id, "status", user.Status)
+	if !approve {
+		b.revokeAccess(ctx, id)
+	}
+
+	name := displayName(tr, user.Name, user.Username)
+	if approve {
+		b.send(tgbotapi.NewMessage(id, b.userLocalizer(id).T("access.approved_user")))
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.approved", name, id)))
+	} else {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("access.banned", name, id)))
+	}
+}
+
+// revokeAccess anula los tokens de la API y del calendario y las sesiones del
+// dashboard de un usuario bloqueado
+func (b *Bot) revokeAccess(ctx context.Context, userID int64) {
+	logger := logging.FromContext(ctx)
+	for name, tokens := range map[string]*auth.TokenStore{"api": b.tokens, "calendar": b.calendars} {
+		if tokens == nil {
+			continue
+		}
+		if n, err := tokens.Revoke(userID); err != nil {
+			logger.Error("Error revoking tokens", "user_id", userID, "tokens", name, "error", err)
+		} else if n > 0 {
+			logger.Info("Tokens revoked", "user_id", userID, "tokens", name, "count", n)
+		}
+	}
+	if b.sessions != nil {
+		if n := b.sessions.EndUser(userID); n > 0 {
+			logger.Info("Dashboard sessions ended", "user_id", userID, "count", n)
+		}
+	}
+}
+
+// displayName muestra un usuario por su nombre y su @username, si lo tiene
+func displayName(tr *i18n.Localizer, name, username string) string {
+	switch {
+	case name == "" && username == "":
+		return tr.T("users.no_name")
+	case username == "":
+		return name
+	case name == "":
+		return "@" + username
+	}
+	return name + " (@" + username + ")"
+}
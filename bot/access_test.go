@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"habittracker/auth"
+	"habittracker/backup"
+	"habittracker/habits"
+	"habittracker/settings"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	testAdminID   = 1
+	testAllowedID = 2
+	testUnknownID = 3
+)
+
+func newTestAccessBot(t *testing.T) *Bot {
+	dir := t.TempDir()
+	users, err := auth.NewUserStore(filepath.Join(dir, "users.json"), []int64{testAllowedID}, []int64{testAdminID})
+	if err != nil {
+		t.Fatalf("Failed to create user store: %v", err)
+	}
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	return &Bot{habitManager: hm, outbox: NewOutbox(&fakeSender{}, ""), users: users, reviews: &Reviews{}}
+}
+
+func privateMessage(userID int64, text string) tgbotapi.Update {
+	msg := &tgbotapi.Message{
+		From: &tgbotapi.User{ID: userID, FirstName: "Ana"},
+		Chat: &tgbotapi.Chat{ID: userID, Type: "private"},
+		Text: text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command := strings.SplitN(text, " ", 2)[0]
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(command)}}
+	}
+	return tgbotapi.Update{Message: msg}
+}
+
+// takeSent devuelve los mensajes encolados y vacía la cola
+func takeSent(b *Bot) []OutboundMessage {
+	b.outbox.mu.Lock()
+	defer b.outbox.mu.Unlock()
+	sent := b.outbox.queue
+	b.outbox.queue = nil
+	return sent
+}
+
+// TestAccessControl prueba la solicitud de acceso, la aprobación y el bloqueo
+func TestAccessControl(t *testing.T) {
+	b := newTestAccessBot(t)
+	ctx := context.Background()
+
+	// Los usuarios permitidos usan el bot y pasan a ser el destino de las notificaciones
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/listhabits"))
+	if b.GetUserChatID() != testAllowedID {
+		t.Errorf("Expected allowed user to become the notification target, got %d", b.GetUserChatID())
+	}
+	takeSent(b)
+
+	// Un desconocido no llega a los comandos: pide acceso y se avisa a los administradores
+	b.processUpdate(ctx, privateMessage(testUnknownID, "/addhabit Correr"))
+	if len(b.habitManager.GetHabits()) != 0 {
+		t.Fatal("Unknown user must not reach command handlers")
+	}
+	sent := takeSent(b)
+	if len(sent) != 2 || sent[0].ChatID != testUnknownID || sent[1].ChatID != testAdminID || !strings.Contains(sent[1].Text, "/approve 3") {
+		t.Fatalf("Expected reply to the user and a notice to the admin, got %+v", sent)
+	}
+
+	// Un segundo mensaje no vuelve a avisar a los administradores
+	b.processUpdate(ctx, privateMessage(testUnknownID, "hola"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "pendiente") {
+		t.Errorf("Expected a single pending notice, got %+v", sent)
+	}
+
+	// Solo los administradores aprueban
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/approve 3"))
+	if b.users.Status(testUnknownID) != auth.StatusPending {
+		t.Fatal("Non-admin must not approve users")
+	}
+	takeSent(b)
+
+	b.processUpdate(ctx, privateMessage(testAdminID, "/approve 3"))
+	if b.users.Status(testUnknownID) != auth.StatusApproved {
+		t.Fatal("Expected user to be approved")
+	}
+	if sent := takeSent(b); len(sent) != 2 || sent[0].ChatID != testUnknownID {
+		t.Errorf("Expected the approved user to be notified, got %+v", sent)
+	}
+
+	// Los aprobados con /approve usan el bot pero no los hábitos, ni pasan a
+	// ser el destino de las notificaciones
+	b.SetUserChatID(0)
+	b.processUpdate(ctx, privateMessage(testUnknownID, "/addhabit Correr"))
+	if len(b.habitManager.GetHabits()) != 0 {
+		t.Fatal("Approved guest must not change the habits")
+	}
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "dueños") {
+		t.Errorf("Expected an owner-only notice, got %+v", sent)
+	}
+	if b.GetUserChatID() != 0 {
+		t.Errorf("Approved guest must not become the notification target, got %d", b.GetUserChatID())
+	}
+	b.processUpdate(ctx, privateMessage(testUnknownID, "/help"))
+	if sent := takeSent(b); len(sent) != 1 || strings.Contains(sent[0].Text, "/deletehabit") || !strings.Contains(sent[0].Text, "/language") {
+		t.Errorf("Expected help without habit commands, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/addhabit Correr"))
+	takeSent(b)
+
+	// Al bloquear se anulan sus tokens y sus sesiones del dashboard
+	dir := t.TempDir()
+	b.tokens, _ = auth.NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+	b.calendars, _ = auth.NewTokenStore(filepath.Join(dir, "calendar_tokens.json"))
+	b.sessions = auth.NewSessionStore()
+	b.tokens.Issue(testUnknownID)
+	b.calendars.Issue(testUnknownID)
+	code, _ := b.sessions.IssueLoginCode(testUnknownID)
+	session, _, _ := b.sessions.Redeem(code)
+
+	// Los bloqueados se ignoran sin respuesta; los administradores no se pueden bloquear
+	b.processUpdate(ctx, privateMessage(testAdminID, "/ban 3"))
+	takeSent(b)
+	if b.tokens.Count(testUnknownID) != 0 || b.calendars.Count(testUnknownID) != 0 {
+		t.Error("Expected the banned user's tokens to be revoked")
+	}
+	if _, err := b.sessions.Validate(session); err == nil {
+		t.Error("Expected the banned user's dashboard session to be ended")
+	}
+	b.processUpdate(ctx, privateMessage(testUnknownID, "/addhabit Leer"))
+	if len(b.habitManager.GetHabits()) != 1 || len(takeSent(b)) != 0 {
+		t.Error("Banned user must be ignored")
+	}
+
+	b.processUpdate(ctx, privateMessage(testAdminID, "/ban 1"))
+	if b.users.Status(testAdminID) != auth.StatusApproved {
+		t.Error("Admins must not be banned")
+	}
+}
+
+// TestAdminCommands prueba que los comandos de administración aceptan a
+// quienes la ayuda muestra como administradores: los de ADMIN_USER_IDS y
+// quien escribe desde el chat de administración
+func TestAdminCommands(t *testing.T) {
+	b := newTestAccessBot(t)
+	fake := newFakeAPI(t, b)
+	b.SetBackups(backup.NewManager(t.TempDir(), t.TempDir(), backup.Retention{Daily: 1}), -100)
+	ctx := context.Background()
+
+	// Un administrador que escribe desde su chat privado
+	b.processUpdate(ctx, privateMessage(testAdminID, "/backup"))
+	if len(fake.take("sendDocument")) != 1 {
+		t.Error("Expected the backup to be sent to the admin")
+	}
+	for _, m := range takeSent(b) {
+		if strings.Contains(m.Text, "Solo") {
+			t.Errorf("Admin refused: %q", m.Text)
+		}
+	}
+
+	// Cualquiera que escribe desde el chat de administración
+	update := privateMessage(testAllowedID, "/users")
+	update.Message.Chat = &tgbotapi.Chat{ID: -100, Type: "group"}
+	b.processUpdate(ctx, update)
+	if sent := takeSent(b); len(sent) != 1 || strings.Contains(sent[0].Text, "Solo") {
+		t.Errorf("Expected /users to work from the admin chat, got %+v", sent)
+	}
+
+	// Los demás no ven ni usan los comandos de administración
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/backup"))
+	if len(fake.take("sendDocument")) != 0 {
+		t.Error("Non-admin must not receive backups")
+	}
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "Solo un administrador") {
+		t.Errorf("Expected an admin-only notice, got %+v", sent)
+	}
+}
+
+// TestLanguage prueba el idioma de Telegram, /language y el idioma de las notificaciones
+func TestLanguage(t *testing.T) {
+	b := newTestAccessBot(t)
+	store, err := settings.NewStore(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create settings store: %v", err)
+	}
+	b.SetSettings(store)
+	ctx := context.Background()
+
+	update := privateMessage(testAllowedID, "/listhabits")
+	update.Message.From.LanguageCode = "en-GB"
+	b.processUpdate(ctx, update)
+	if sent := takeSent(b); len(sent) != 1 || !strings.HasPrefix(sent[0].Text, "You don't have any habits") {
+		t.Fatalf("Expected an English reply, got %+v", sent)
+	}
+	if b.userLocalizer(testAllowedID).Lang() != "en" {
+		t.Error("Expected the Telegram language to be remembered for notifications")
+	}
+
+	update = privateMessage(testAllowedID, "/language es")
+	update.Message.From.LanguageCode = "en"
+	b.processUpdate(ctx, update)
+	takeSent(b)
+
+	update = privateMessage(testAllowedID, "/listhabits")
+	update.Message.From.LanguageCode = "en"
+	b.processUpdate(ctx, update)
+	if sent := takeSent(b); len(sent) != 1 || !strings.HasPrefix(sent[0].Text, "No tienes hábitos") {
+		t.Fatalf("Expected the chosen language to win over Telegram's, got %+v", sent)
+	}
+
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/language fr"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "en (English)") {
+		t.Errorf("Expected the available languages, got %+v", sent)
+	}
+}
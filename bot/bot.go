@@ -1,35 +1,72 @@
 package bot
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"habittracker/auth"
+	"habittracker/backup"
 	"habittracker/habits"
-	"log"
+	"habittracker/habits/importers"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/metrics"
+	"habittracker/settings"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type Bot struct {
-	api          *tgbotapi.BotAPI
-	habitManager *habits.HabitManager
-	userChatID   int64
+	api           *tgbotapi.BotAPI
+	habitManager  *habits.HabitManager
+	outbox        *Outbox
+	tokens        *auth.TokenStore
+	sessions      *auth.SessionStore
+	dashboardURL  string
+	calendars     *auth.TokenStore
+	calendarURL   string
+	backups       *backup.Manager
+	adminChatID   int64
+	users         *auth.UserStore
+	settings      *settings.Store
+	templates     *Templates
+	conversations *Conversations
+	reminders     *Reminders
+	reviews       *Reviews
+	quietMode     string
+	loc           *time.Location
+	callbackKey   []byte
+	webhookSecret string
+	userChatID    atomic.Int64 // Chat de las notificaciones; lo leen las tareas programadas
+	pingMu        sync.Mutex
+	pingOK        time.Time // Última respuesta de Telegram a Ping
 }
 
-func NewBot(token string, habitManager *habits.HabitManager) (*Bot, error) {
+func NewBot(token string, habitManager *habits.HabitManager, outboxFile string) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	log.Printf("Authorized on account %s", api.Self.UserName)
+	slog.Info("Authorized on Telegram", "account", api.Self.UserName)
 
 	return &Bot{
 		api:          api,
 		habitManager: habitManager,
+		outbox:       NewOutbox(api, outboxFile),
+		reviews:      &Reviews{},
+		callbackKey:  callbackKey(token),
 	}, nil
 }
 
@@ -40,260 +77,588 @@ func (b *Bot) Start() {
 
 	updates := b.api.GetUpdatesChan(u)
 
-	log.Println("Bot started, waiting for messages...")
+	slog.Info("Bot started, waiting for messages")
 
 	for update := range updates {
+		b.processUpdate(context.Background(), update)
+	}
+}
+
+// processUpdate despacha un update y registra sus métricas.
+// El logger del contexto lleva update_id y chat_id para todo el procesamiento.
+func (b *Bot) processUpdate(ctx context.Context, update tgbotapi.Update) {
+	start := time.Now()
+	updateType := "other"
+
+	logger := logging.FromContext(ctx).With("update_id", update.UpdateID)
+	if chat := update.FromChat(); chat != nil {
+		logger = logger.With("chat_id", chat.ID)
+	}
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = i18n.WithLocalizer(ctx, b.localizer(update.SentFrom()))
+
+	if !b.authorize(ctx, update) {
+		updateType = "unauthorized"
+	} else {
+		b.rememberUser(ctx, update.SentFrom())
+
 		if update.Message != nil {
-			b.handleMessage(update.Message)
+			updateType = "message"
+			b.handleMessage(ctx, update.Message)
 		} else if update.CallbackQuery != nil {
-			b.handleCallback(update.CallbackQuery)
+			updateType = "callback_query"
+			b.handleCallback(ctx, update.CallbackQuery)
 		}
 	}
+
+	metrics.UpdatesTotal.Inc(updateType)
+	metrics.UpdateDuration.ObserveSince(updateType, start)
+	logger.Debug("Update processed", "type", updateType, "duration", time.Since(start))
 }
 
 // handleMessage maneja los mensajes de texto
-func (b *Bot) handleMessage(message *tgbotapi.Message) {
-	// Guardar el chat ID del usuario para notificaciones
-	if b.userChatID == 0 {
-		b.userChatID = message.Chat.ID
-		log.Printf("User chat ID saved: %d", b.userChatID)
+func (b *Bot) handleMessage(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+
+	// Guardar el chat del dueño de los hábitos para las notificaciones. En modo
+	// privado los usuarios aprobados con /approve no pasan a ser el destino.
+	if message.From != nil && b.ownsHabits(message.From.ID) && b.userChatID.CompareAndSwap(0, message.Chat.ID) {
+		logger.Info("User chat ID saved")
 	}
 
-	if !message.IsCommand() {
+	if message.Document != nil {
+		if !b.requireOwner(ctx, message) {
+			return
+		}
+		b.handleDocument(ctx, message)
 		return
 	}
 
-	switch message.Command() {
-	case "start":
-		b.handleStart(message)
-	case "help":
-		b.handleHelp(message)
-	case "addhabit":
-		b.handleAddHabit(message)
-	case "listhabits":
-		b.handleListHabits(message)
-	case "deletehabit":
-		b.handleDeleteHabit(message)
-	default:
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Comando no reconocido. Usa /help para ver los comandos disponibles.")
-		b.api.Send(msg)
+	// Los mensajes que no son comandos responden a un asistente en curso
+	if !message.IsCommand() {
+		b.continueConversation(ctx, message)
+		return
 	}
+
+	logger.Info("Command received", "command", message.Command())
+	b.dispatchCommand(ctx, message)
 }
 
 // handleStart maneja el comando /start
-func (b *Bot) handleStart(message *tgbotapi.Message) {
-	text := "¡Bienvenido al Habit Tracker Bot! 🎯\n\n" +
-		"Este bot te ayudará a rastrear tus hábitos diarios.\n" +
-		"📅 *Rutina Diaria:*\n" +
-		"🌅 08:00 AM - Planificación del día\n" +
-		"🌙 09:00 PM - Revisión de progreso\n\n" +
-		"Usa /help para ver todos los comandos disponibles."
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, text)
-	b.api.Send(msg)
+func (b *Bot) handleStart(ctx context.Context, message *tgbotapi.Message) {
+	b.send(htmlMessage(message.Chat.ID, i18n.FromContext(ctx), "start"))
 }
 
 // handleHelp maneja el comando /help
-func (b *Bot) handleHelp(message *tgbotapi.Message) {
-	text := "📋 *Comandos disponibles:*\n\n" +
-		"/start - Iniciar el bot\n" +
-		"/help - Mostrar esta ayuda\n" +
-		"/addhabit <nombre> - Agregar un nuevo hábito\n" +
-		"/listhabits - Listar todos tus hábitos\n" +
-		"/deletehabit <id> - Eliminar un hábito\n\n" +
-		"💡 *Ejemplo:*\n" +
-		"`/addhabit Hacer ejercicio`"
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, text)
-	msg.ParseMode = "Markdown"
-	b.api.Send(msg)
+func (b *Bot) handleHelp(ctx context.Context, message *tgbotapi.Message) {
+	admin := b.isAdmin(senderID(message), message.Chat.ID)
+	owner := b.ownsHabits(senderID(message))
+	b.send(htmlText(message.Chat.ID, helpText(i18n.FromContext(ctx), admin, owner)))
 }
 
 // handleAddHabit maneja el comando /addhabit
-func (b *Bot) handleAddHabit(message *tgbotapi.Message) {
+func (b *Bot) handleAddHabit(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
 	args := message.CommandArguments()
 	if args == "" {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Por favor proporciona un nombre para el hábito.\nEjemplo: /addhabit Hacer ejercicio")
-		b.api.Send(msg)
+		// Sin nombre, el asistente pregunta los datos del hábito paso a paso
+		if b.startConversation(ctx, message, "addhabit") {
+			return
+		}
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("addhabit.usage"))
+		b.send(msg)
 		return
 	}
 
 	habit, err := b.habitManager.AddHabit(args, "")
 	if err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Error al agregar hábito: %v", err))
-		b.api.Send(msg)
+		logging.FromContext(ctx).Error("Error adding habit", "error", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("addhabit.error", err))
+		b.send(msg)
 		return
 	}
 
-	text := fmt.Sprintf("✅ Hábito agregado exitosamente!\n\nID: %d\nNombre: %s", habit.ID, habit.Name)
+	text := tr.T("addhabit.added", habit.ID, habit.Name)
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
-	b.api.Send(msg)
+	b.send(msg)
 }
 
 // handleListHabits maneja el comando /listhabits
-func (b *Bot) handleListHabits(message *tgbotapi.Message) {
-	habits := b.habitManager.GetHabits()
+func (b *Bot) handleListHabits(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	habits := b.habitManager.GetActiveHabits()
 
 	if len(habits) == 0 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "No tienes hábitos configurados aún.\nUsa /addhabit para agregar uno.")
-		b.api.Send(msg)
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("listhabits.empty"))
+		b.send(msg)
 		return
 	}
 
 	var text strings.Builder
-	text.WriteString("📋 *Tus hábitos:*\n\n")
+	text.WriteString(tr.T("listhabits.title") + "\n\n")
 
+	today := b.today()
 	for _, habit := range habits {
-		text.WriteString(fmt.Sprintf("*ID %d:* %s\n", habit.ID, habit.Name))
+		fmt.Fprintf(&text, "<b>ID %d:</b> %s", habit.ID, escapeHTML(habit.Name))
+		if habit.PausedOn(today) {
+			text.WriteString(" ⏸️")
+		}
+		text.WriteString("\n")
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, text.String())
-	msg.ParseMode = "Markdown"
-	b.api.Send(msg)
+	b.send(htmlText(message.Chat.ID, text.String()))
 }
 
 // handleDeleteHabit maneja el comando /deletehabit
-func (b *Bot) handleDeleteHabit(message *tgbotapi.Message) {
+func (b *Bot) handleDeleteHabit(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
 	args := message.CommandArguments()
 	if args == "" {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Por favor proporciona el ID del hábito a eliminar.\nEjemplo: /deletehabit 1")
-		b.api.Send(msg)
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("deletehabit.usage"))
+		b.send(msg)
 		return
 	}
 
 	id, err := strconv.Atoi(args)
 	if err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "ID inválido. Debe ser un número.")
-		b.api.Send(msg)
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("invalid_id"))
+		b.send(msg)
 		return
 	}
 
 	if err := b.habitManager.DeleteHabit(id); err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Error: %v", err))
-		b.api.Send(msg)
+		msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err))
+		b.send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.Chat.ID, "✅ Hábito eliminado exitosamente!")
-	b.api.Send(msg)
+	msg := tgbotapi.NewMessage(message.Chat.ID, tr.T("deletehabit.deleted"))
+	b.send(msg)
 }
 
-// handleCallback maneja las respuestas de los botones inline
-func (b *Bot) handleCallback(callback *tgbotapi.CallbackQuery) {
-	data := callback.Data
-	parts := strings.Split(data, "_")
+// handleAPIToken maneja el comando /apitoken
+func (b *Bot) handleAPIToken(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
 
-	// Esperamos formato: type_action_id (ej: plan_yes_1, review_no_2)
-	// O legacy: action_id (ej: yes_1) -> lo tratamos como review por compatibilidad si es necesario, o lo ignoramos.
+	if b.tokens == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("apitoken.disabled")))
+		return
+	}
 
-	if len(parts) < 2 {
+	if message.From == nil {
 		return
 	}
+	userID := message.From.ID
 
-	var actionType, response string
-	var habitID int
-	var err error
+	if strings.TrimSpace(message.CommandArguments()) == "revoke" {
+		removed, err := b.tokens.Revoke(userID)
+		if err != nil {
+			logger.Error("Error revoking API tokens", "error", err)
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("apitoken.revoke_error")))
+			return
+		}
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.N("apitoken.revoked", removed)))
+		return
+	}
+
+	token, err := b.tokens.Issue(userID)
+	if err != nil {
+		logger.Error("Error issuing API token", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("apitoken.issue_error")))
+		return
+	}
+	logger.Info("API token issued", "user_id", userID)
+
+	b.send(htmlMessage(message.Chat.ID, tr, "apitoken.issued", token))
+}
 
-	if len(parts) == 3 {
-		actionType = parts[0] // plan o review
-		response = parts[1]   // yes o no
-		habitID, err = strconv.Atoi(parts[2])
-	} else if len(parts) == 2 {
-		// Legacy support (asumimos review)
-		actionType = "review"
-		response = parts[0]
-		habitID, err = strconv.Atoi(parts[1])
+// handleDashboard maneja el comando /dashboard enviando un enlace de acceso de un solo uso
+func (b *Bot) handleDashboard(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if b.sessions == nil || b.dashboardURL == "" || message.From == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("dashboard.disabled")))
+		return
 	}
 
+	code, err := b.sessions.IssueLoginCode(message.From.ID)
 	if err != nil {
+		logging.FromContext(ctx).Error("Error issuing dashboard login code", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("dashboard.error")))
 		return
 	}
 
-	// Obtener el nombre del hábito
-	habits := b.habitManager.GetHabits()
-	var habitName string
-	for _, h := range habits {
-		if h.ID == habitID {
-			habitName = h.Name
-			break
+	link := fmt.Sprintf("%s/dashboard/login?code=%s", b.dashboardURL, code)
+	text := tr.N("dashboard.link", int(b.sessions.LoginTTL.Minutes()), link)
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// handleExport maneja el comando /export [csv|json] [desde] [hasta] enviando un archivo
+func (b *Bot) handleExport(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	format := habits.ExportCSV
+	var dates []string
+	for _, arg := range strings.Fields(message.CommandArguments()) {
+		switch strings.ToLower(arg) {
+		case habits.ExportCSV, habits.ExportJSON:
+			format = strings.ToLower(arg)
+		default:
+			dates = append(dates, arg)
 		}
 	}
+	if len(dates) > 2 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("export.usage")))
+		return
+	}
+
+	var from, to string
+	if len(dates) > 0 {
+		from = dates[0]
+	}
+	if len(dates) > 1 {
+		to = dates[1]
+	}
+
+	export, err := b.habitManager.Export(from, to, b.now())
+	if err != nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("export.invalid_dates")))
+		return
+	}
 
-	var responseText string
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format); err != nil {
+		logger.Error("Error writing export", "format", format, "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("export.error")))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("habits_%s_%s.%s", export.From, export.To, format),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = tr.T("export.caption", export.From, export.To,
+		tr.N("export.habits", len(export.Habits)), tr.N("export.rows", len(export.Days)))
+	if err := b.sendDocument(doc); err != nil {
+		logger.Error("Error sending export", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("export.send_error")))
+		return
+	}
+	logger.Info("Export sent", "format", format, "rows", len(export.Days))
+}
+
+// handleSchedule maneja el comando /schedule <id> <días> [HH:MM]
+func (b *Bot) handleSchedule(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	usage := tr.T("schedule.usage")
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) < 2 || len(args) > 3 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, usage))
+		return
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("invalid_id")))
+		return
+	}
+	weekdays, err := habits.ParseWeekdays(args[1])
+	if err != nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("schedule.invalid_days", usage)))
+		return
+	}
+	reminderTime := ""
+	if len(args) == 3 {
+		reminderTime = args[2]
+	}
+
+	habit, err := b.habitManager.SetSchedule(id, weekdays, reminderTime)
+	if err != nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+		return
+	}
+
+	text := tr.T("schedule.updated", habit.Name, tr.Weekdays(habit.Weekdays))
+	if habit.ReminderTime != "" {
+		text = tr.T("schedule.updated_at", habit.Name, tr.Weekdays(habit.Weekdays), habit.ReminderTime)
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// handleCalendar maneja el comando /calendar enviando la URL secreta del feed .ics.
+// Cada pedido genera una URL nueva e invalida las anteriores.
+func (b *Bot) handleCalendar(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	if b.calendars == nil || b.calendarURL == "" || message.From == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("calendar.disabled")))
+		return
+	}
+	userID := message.From.ID
+
+	if _, err := b.calendars.Revoke(userID); err != nil {
+		logger.Error("Error revoking calendar tokens", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("calendar.error")))
+		return
+	}
+	if strings.TrimSpace(message.CommandArguments()) == "revoke" {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("calendar.revoked")))
+		return
+	}
+
+	token, err := b.calendars.Issue(userID)
+	if err != nil {
+		logger.Error("Error issuing calendar token", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("calendar.error")))
+		return
+	}
+	logger.Info("Calendar feed issued", "user_id", userID)
+
+	text := tr.T("calendar.url", fmt.Sprintf("%s/calendar/%s.ics", b.calendarURL, token))
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// maxDocumentSize es el tamaño máximo de un archivo que el bot puede enviar
+const maxDocumentSize = 50 << 20
+
+// handleBackup maneja el comando /backup: crea un backup y lo envía al chat de administración
+func (b *Bot) handleBackup(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	if b.backups == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.disabled")))
+		return
+	}
+	if !b.isAdmin(senderID(message), message.Chat.ID) {
+		logger.Warn("Backup requested by a non-admin user")
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.not_admin")))
+		return
+	}
+
+	now := b.now()
+	path, removed, err := b.backups.Run(now)
+	if err != nil {
+		logger.Error("Error creating backup", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.error")))
+		return
+	}
+	logger.Info("Backup created", "path", path, "pruned", len(removed))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		logger.Error("Error reading backup", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.read_error")))
+		return
+	}
+	if info.Size() > maxDocumentSize {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.too_large", path)))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FilePath(path))
+	doc.Caption = tr.T("backup.caption", now.Format("2006-01-02 15:04"))
+	if err := b.sendDocument(doc); err != nil {
+		logger.Error("Error sending backup", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("backup.send_error")))
+	}
+}
+
+// maxImportSize es el tamaño máximo de un archivo a importar
+const maxImportSize = 10 << 20
+
+// handleDocument importa el historial de otra aplicación enviado como archivo
+func (b *Bot) handleDocument(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+	doc := message.Document
+
+	if doc.FileSize > maxImportSize {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("import.too_large")))
+		return
+	}
+
+	data, err := b.downloadFile(doc.FileID)
+	if err != nil {
+		logger.Error("Error downloading document", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("import.download_error")))
+		return
+	}
+
+	imp, err := importers.Parse(doc.FileName, data)
+	if err != nil {
+		logger.Warn("Unsupported import file", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("import.unsupported")))
+		return
+	}
+
+	result, err := b.habitManager.Merge(imp.Habits, imp.Logs, false)
+	if err != nil {
+		logger.Error("Error importing data", "source", imp.Source, "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("import.save_error")))
+		return
+	}
+	logger.Info("Data imported", "source", imp.Source,
+		"habits_added", result.HabitsAdded, "logs_added", result.LogsAdded, "logs_skipped", result.LogsSkipped)
+
+	b.send(htmlMessage(message.Chat.ID, tr, "import.done",
+		result.HabitsAdded, result.HabitsMatched, result.LogsAdded, result.LogsSkipped))
+}
+
+// downloadFile descarga un archivo enviado al bot
+func (b *Bot) downloadFile(fileID string) ([]byte, error) {
+	fileURL, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+}
+
+// handleCallback maneja las respuestas de los botones inline
+func (b *Bot) handleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+	data, err := parseCallbackData(b.callbackKey, callback.Data, callback.From.ID)
+	if err != nil {
+		logger.Warn("Rejected callback data", "reason", err.Error(), "data", callback.Data, "from_id", callback.From.ID)
+		metrics.CallbacksRejectedTotal.Inc(err.Error())
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+		return
+	}
+	if !b.ownsHabits(callback.From.ID) {
+		logger.Warn("Callback from a user who does not own the habits", "from_id", callback.From.ID)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("access.owner_only")))
+		return
+	}
+	switch data.Type {
+	case "today":
+		b.handleTodayCallback(ctx, callback, data)
+		return
+	case "remind":
+		b.handleReminderCallback(ctx, callback, data)
+		return
+	case "resolve":
+		b.handleResolveCallback(ctx, callback, data)
+		return
+	}
+	// La respuesta se registra en el día de la pregunta, aunque se presione
+	// después de medianoche o después de cerrar la revisión
+	actionType, habitID := data.Type, data.HabitID
+	answer, day, err := b.parseAnswer(data.Action)
+	if err != nil {
+		logger.Warn("Invalid habit button", "action", data.Action, "error", err)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+		return
+	}
+	date := day.Format(habits.DateFormat)
 
 	if actionType == "plan" {
-		planned := response == "yes"
-		if err := b.habitManager.RecordPlan(habitID, planned); err != nil {
-			log.Printf("Error recording plan: %v", err)
+		planned := answer
+		if err := b.habitManager.RecordPlan(date, habitID, planned); err != nil {
+			logger.Error("Error recording plan", "habit_id", habitID, "error", err)
 			return
 		}
-
-		if planned {
-			responseText = fmt.Sprintf("👍 Planeado: '%s'", habitName)
-		} else {
-			responseText = fmt.Sprintf("⏭️ Saltado por hoy: '%s'", habitName)
-		}
-
 	} else if actionType == "review" {
-		completed := response == "yes"
-		if err := b.habitManager.RecordCompletion(habitID, completed); err != nil {
-			log.Printf("Error recording completion: %v", err)
+		completed := answer
+		if err := b.habitManager.RecordCompletion(date, habitID, completed); err != nil {
+			logger.Error("Error recording completion", "habit_id", habitID, "error", err)
 			return
 		}
+	}
 
-		if completed {
-			responseText = fmt.Sprintf("✅ Completado: '%s'", habitName)
-		} else {
-			responseText = fmt.Sprintf("❌ No completado: '%s'", habitName)
-		}
+	tmplData := b.templateData(tr, callback.From.ID, day)
+	if habit, err := b.habitManager.GetHabit(habitID); err == nil {
+		tmplData.Habit = b.templateHabits([]habits.Habit{*habit}, tmplData.Date)[0]
 	}
+	tmplData.Action = actionType
+	tmplData.Done = answer
+	responseText := b.render(tr, "confirmation", tmplData)
 
 	// Responder al callback
-	callbackConfig := tgbotapi.NewCallback(callback.ID, responseText)
-	b.api.Send(callbackConfig)
+	callbackConfig := tgbotapi.NewCallback(callback.ID, plainText(responseText))
+	b.request(callbackConfig)
 
 	// Actualizar el mensaje original para quitar los botones y mostrar la elección
 	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, responseText)
-	b.api.Send(edit)
+	edit.ParseMode = tgbotapi.ModeHTML
+	b.request(edit)
+
+	if actionType == "review" {
+		b.finishReview(tr, callback.Message.Chat.ID, habitID, day)
+	}
+}
+
+// parseAnswer lee la respuesta (yes o no) y el día de la pregunta de un botón
+// de planificación o revisión. Los botones sin fecha, enviados por versiones
+// anteriores, se registran hoy.
+func (b *Bot) parseAnswer(action string) (bool, time.Time, error) {
+	for _, answer := range []string{"yes", "no"} {
+		date, ok := strings.CutPrefix(action, answer)
+		if !ok {
+			continue
+		}
+		if date == "" {
+			return answer == "yes", b.now(), nil
+		}
+		day, err := time.ParseInLocation(buttonDateFormat, date, b.location())
+		return answer == "yes", day, err
+	}
+	return false, time.Time{}, fmt.Errorf("unknown action %q", action)
 }
 
 // SendMorningGreeting envía el saludo matutino y pregunta qué hábitos se harán hoy
 func (b *Bot) SendMorningGreeting() error {
-	if b.userChatID == 0 {
-		log.Println("No user chat ID available yet, skipping morning greeting")
+	if b.GetUserChatID() == 0 {
+		slog.Warn("No user chat ID available yet, skipping morning greeting")
 		return nil
 	}
 
-	habits := b.habitManager.GetHabits()
+	tr := b.userLocalizer(b.GetUserChatID())
 
-	if len(habits) == 0 {
-		msg := tgbotapi.NewMessage(b.userChatID, "No tienes hábitos configurados. Usa /addhabit para agregar uno.")
-		_, err := b.api.Send(msg)
-		return err
+	// Lo que quedó sin responder en la revisión anterior se ofrece resolver primero
+	now := b.now()
+	b.closeReview(tr, now)
+
+	habits, skipped := b.dueHabits(now.Format("2006-01-02"))
+	if len(habits) == 0 && skipped > 0 {
+		slog.Info("No habits due today, skipping morning greeting")
+		return nil
 	}
 
-	text := "🌅 *Buenos días!* Planifiquemos tu día.\n¿Qué hábitos harás hoy?"
-	msg := tgbotapi.NewMessage(b.userChatID, text)
-	msg.ParseMode = "Markdown"
-	b.api.Send(msg)
+	data := b.templateData(tr, b.GetUserChatID(), now)
+	data.Habits = b.templateHabits(habits, data.Date)
+	if err := b.sendScheduled(htmlText(b.GetUserChatID(), b.render(tr, "greeting", data))); err != nil || len(habits) == 0 {
+		return err
+	}
 
 	// Enviar un mensaje por cada hábito con botones de planificación
 	for _, habit := range habits {
-		habitText := fmt.Sprintf("🎯 *%s*", habit.Name)
-		habitMsg := tgbotapi.NewMessage(b.userChatID, habitText)
-		habitMsg.ParseMode = "Markdown"
+		habitMsg := htmlMessage(b.GetUserChatID(), tr, "morning.habit", habit.Name)
 
 		// Crear botones inline
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("👍 Lo haré", fmt.Sprintf("plan_yes_%d", habit.ID)),
-				tgbotapi.NewInlineKeyboardButtonData("⏭️ Hoy no", fmt.Sprintf("plan_no_%d", habit.ID)),
-			),
-		)
+		keyboard, err := b.habitKeyboard("plan", habit.ID, now, tr.T("morning.yes"), tr.T("morning.no"))
+		if err != nil {
+			slog.Error("Error building habit planner", "habit_id", habit.ID, "error", err)
+			continue
+		}
 		habitMsg.ReplyMarkup = keyboard
 
-		if _, err := b.api.Send(habitMsg); err != nil {
-			log.Printf("Error sending habit planner: %v", err)
+		if err := b.sendScheduled(habitMsg); err != nil {
+			slog.Error("Error sending habit planner", "habit_id", habit.ID, "error", err)
 		}
 	}
 
@@ -302,21 +667,21 @@ func (b *Bot) SendMorningGreeting() error {
 
 // SendEveningReview envía la revisión nocturna de los hábitos planeados
 func (b *Bot) SendEveningReview() error {
-	if b.userChatID == 0 {
-		log.Println("No user chat ID available yet, skipping evening review")
+	if b.GetUserChatID() == 0 {
+		slog.Warn("No user chat ID available yet, skipping evening review")
 		return nil
 	}
 
+	tr := b.userLocalizer(b.GetUserChatID())
+
 	// Obtener planes de hoy
-	now := time.Now()
+	now := b.now()
 	date := now.Format("2006-01-02")
 	dailyPlans := b.habitManager.GetDailyPlans(date)
-	allHabits := b.habitManager.GetHabits()
-
-	// Mapa para acceso rápido a hábitos
-	habitMap := make(map[int]string)
-	for _, h := range allHabits {
-		habitMap[h.ID] = h.Name
+	allHabits, skipped := b.dueHabits(date)
+	if len(allHabits) == 0 && skipped > 0 {
+		slog.Info("No habits due today, skipping evening review")
+		return nil
 	}
 
 	// Filtrar hábitos que se planearon hacer (o todos si no hubo planificación explícita, decisión de diseño)
@@ -324,7 +689,7 @@ func (b *Bot) SendEveningReview() error {
 	// O simplificamos: preguntamos por TODOS los hábitos activos, pero personalizamos el mensaje si dijeron que NO.
 	// Vamos a preguntar por los que dijeron SI o no respondieron.
 
-	var habitsToReview []int
+	var habitsToReview []habits.Habit
 	plannedMap := make(map[int]bool)
 
 	for _, plan := range dailyPlans {
@@ -336,75 +701,267 @@ func (b *Bot) SendEveningReview() error {
 		// Si dijo que SI (planned=true) O no respondió (!responded), preguntamos.
 		// Si dijo que NO (planned=false), no preguntamos (respetamos su decisión matutina).
 		if !responded || planned {
-			habitsToReview = append(habitsToReview, h.ID)
+			habitsToReview = append(habitsToReview, h)
 		}
 	}
 
+	data := b.templateData(tr, b.GetUserChatID(), now)
+	data.Habits = b.templateHabits(habitsToReview, date)
+	b.sendScheduled(htmlText(b.GetUserChatID(), b.render(tr, "review", data)))
 	if len(habitsToReview) == 0 {
-		msg := tgbotapi.NewMessage(b.userChatID, "🌙 *Buenas noches!* Hoy no planificaste ningún hábito. ¡Mañana será otro día!")
-		msg.ParseMode = "Markdown"
-		b.api.Send(msg)
 		return nil
 	}
 
-	text := "🌙 *Buenas noches!* Es hora de revisar tu progreso de hoy."
-	msg := tgbotapi.NewMessage(b.userChatID, text)
-	msg.ParseMode = "Markdown"
-	b.api.Send(msg)
+	b.startReview(date, habitsToReview)
 
-	for _, habitID := range habitsToReview {
-		name := habitMap[habitID]
-		habitText := fmt.Sprintf("❓ *%s*\n¿Lo completaste?", name)
-		habitMsg := tgbotapi.NewMessage(b.userChatID, habitText)
-		habitMsg.ParseMode = "Markdown"
+	for _, habit := range habitsToReview {
+		b.sendReviewHabit(tr, habit, now)
+	}
 
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("✅ Sí", fmt.Sprintf("review_yes_%d", habitID)),
-				tgbotapi.NewInlineKeyboardButtonData("❌ No", fmt.Sprintf("review_no_%d", habitID)),
-			),
-		)
-		habitMsg.ReplyMarkup = keyboard
+	return nil
+}
+
+// sendReviewHabit envía la pregunta de la revisión nocturna de un hábito para el día de day
+func (b *Bot) sendReviewHabit(tr *i18n.Localizer, habit habits.Habit, day time.Time) {
+	habitMsg := htmlMessage(b.GetUserChatID(), tr, "evening.habit", habit.Name)
+
+	keyboard, err := b.habitKeyboard("review", habit.ID, day, tr.T("evening.yes"), tr.T("evening.no"))
+	if err != nil {
+		slog.Error("Error building habit review", "habit_id", habit.ID, "error", err)
+		return
+	}
+	habitMsg.ReplyMarkup = keyboard
 
-		if _, err := b.api.Send(habitMsg); err != nil {
-			log.Printf("Error sending habit review: %v", err)
+	if err := b.sendScheduled(habitMsg); err != nil {
+		slog.Error("Error sending habit review", "habit_id", habit.ID, "error", err)
+	}
+}
+
+// dueHabits devuelve los hábitos activos que corresponden en date (según sus
+// días de la semana y sus pausas) y cuántos no corresponden
+func (b *Bot) dueHabits(date string) ([]habits.Habit, int) {
+	var list []habits.Habit
+	skipped := 0
+	for _, habit := range b.habitManager.GetActiveHabits() {
+		if !habit.DueOn(date) {
+			skipped++
+			continue
 		}
+		list = append(list, habit)
+	}
+	return list, skipped
+}
+
+// habitKeyboard arma los botones sí/no de un hábito para el día de day,
+// firmados para el usuario
+func (b *Bot) habitKeyboard(actionType string, habitID int, day time.Time, yesText, noText string) (tgbotapi.InlineKeyboardMarkup, error) {
+	date := day.Format(buttonDateFormat)
+	yes, err := callbackData{Type: actionType, Action: "yes" + date, HabitID: habitID, Owner: b.GetUserChatID()}.encode(b.callbackKey)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	no, err := callbackData{Type: actionType, Action: "no" + date, HabitID: habitID, Owner: b.GetUserChatID()}.encode(b.callbackKey)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(yesText, yes),
+			tgbotapi.NewInlineKeyboardButtonData(noText, no),
+		),
+	), nil
+}
+
+// send encola un mensaje en la cola de salida
+func (b *Bot) send(msg tgbotapi.MessageConfig) error {
+	return b.enqueue(outboundMessage(msg))
+}
+
+// enqueue agrega un mensaje a la cola de salida
+func (b *Bot) enqueue(out OutboundMessage) error {
+	if err := b.outbox.Enqueue(out); err != nil {
+		slog.Error("Error enqueueing message", "chat_id", out.ChatID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// outboundMessage convierte un MessageConfig en un mensaje de la cola de salida
+func outboundMessage(msg tgbotapi.MessageConfig) OutboundMessage {
+	out := OutboundMessage{
+		ChatID:    msg.ChatID,
+		Text:      msg.Text,
+		ParseMode: msg.ParseMode,
+	}
+	if keyboard, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+		out.Keyboard = &keyboard
+	}
+	return out
+}
+
+// request envía directamente una petición que no devuelve un mensaje (callbacks, ediciones)
+func (b *Bot) request(c tgbotapi.Chattable) {
+	start := time.Now()
+	if _, err := b.api.Request(c); err != nil {
+		slog.Error("Error sending request", "error", err)
+		metrics.SendFailuresTotal.Inc("request")
+		metrics.SendDuration.ObserveSince("error", start)
+		return
+	}
+	metrics.SendDuration.ObserveSince("ok", start)
+}
+
+// sendDocument envía un archivo directamente. Los archivos no pasan por la cola
+// de salida porque solo se envían como respuesta inmediata a un comando.
+func (b *Bot) sendDocument(doc tgbotapi.DocumentConfig) error {
+	start := time.Now()
+	if _, err := b.api.Send(doc); err != nil {
+		metrics.SendFailuresTotal.Inc(failureReason(err))
+		metrics.SendDuration.ObserveSince("error", start)
+		return err
 	}
+	metrics.SendDuration.ObserveSince("ok", start)
+	return nil
+}
+
+// StartOutbox inicia la entrega de mensajes encolados
+func (b *Bot) StartOutbox() {
+	b.outbox.Start()
+}
+
+// StopOutbox detiene la entrega; los mensajes pendientes quedan guardados
+func (b *Bot) StopOutbox() {
+	b.outbox.Stop()
+}
 
+// FlushOutbox espera a que se entreguen los mensajes pendientes
+func (b *Bot) FlushOutbox(timeout time.Duration) error {
+	if !b.outbox.WaitEmpty(timeout) {
+		return fmt.Errorf("outbox still has %d pending messages", b.outbox.Pending())
+	}
 	return nil
 }
 
+// PendingMessages devuelve la cantidad de mensajes sin entregar
+func (b *Bot) PendingMessages() int {
+	return b.outbox.Pending()
+}
+
+// Espera máxima de Ping y tiempo durante el que se reutiliza una respuesta
+// correcta, para que cada sondeo de /readyz no sea un pedido a Telegram
+const (
+	pingTimeout = 5 * time.Second
+	pingTTL     = 30 * time.Second
+)
+
+// Ping verifica que la API de Telegram sea alcanzable (para /readyz)
+func (b *Bot) Ping() error {
+	b.pingMu.Lock()
+	defer b.pingMu.Unlock()
+	if time.Since(b.pingOK) < pingTTL {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.api.GetMe()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("telegram unreachable: %w", err)
+		}
+	case <-time.After(pingTimeout):
+		return fmt.Errorf("telegram unreachable: no response in %s", pingTimeout)
+	}
+	b.pingOK = time.Now()
+	return nil
+}
+
+// UserCount devuelve la cantidad de usuarios conocidos por el bot
+func (b *Bot) UserCount() int {
+	if b.GetUserChatID() == 0 {
+		return 0
+	}
+	return 1
+}
+
+// SetTokenStore habilita la emisión de tokens de la API con /apitoken
+func (b *Bot) SetTokenStore(tokens *auth.TokenStore) {
+	b.tokens = tokens
+}
+
+// SetDashboard habilita el comando /dashboard; baseURL es la URL pública del servidor HTTP
+func (b *Bot) SetDashboard(sessions *auth.SessionStore, baseURL string) {
+	b.sessions = sessions
+	b.dashboardURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetCalendar habilita el comando /calendar con los tokens del feed y la URL pública del servidor
+func (b *Bot) SetCalendar(tokens *auth.TokenStore, baseURL string) {
+	b.calendars = tokens
+	b.calendarURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetLocation configura la zona horaria en que se cuentan los días (la del
+// scheduler). Sin configurar se usa la zona del sistema.
+func (b *Bot) SetLocation(loc *time.Location) {
+	b.loc = loc
+}
+
+// location devuelve la zona horaria configurada
+func (b *Bot) location() *time.Location {
+	if b.loc == nil {
+		return time.Local
+	}
+	return b.loc
+}
+
+// now devuelve la hora actual en la zona horaria configurada. Todo lo que
+// depende de qué día es hoy (registros, /today, pausas, recordatorios,
+// silencio) usa esta hora, para no cambiar de fecha según la zona del sistema.
+func (b *Bot) now() time.Time {
+	return time.Now().In(b.location())
+}
+
+// today devuelve la fecha de hoy (YYYY-MM-DD) en la zona horaria configurada
+func (b *Bot) today() string {
+	return b.now().Format(habits.DateFormat)
+}
+
+// SetBackups habilita el comando /backup para los administradores;
+// adminChatID es el chat de administración (ADMIN_CHAT_ID)
+func (b *Bot) SetBackups(backups *backup.Manager, adminChatID int64) {
+	b.backups = backups
+	b.adminChatID = adminChatID
+}
+
 // GetUserChatID devuelve el chat ID del usuario
 func (b *Bot) GetUserChatID() int64 {
-	return b.userChatID
+	return b.userChatID.Load()
 }
 
 // SetUserChatID configura manualmente el chat ID del usuario (útil para testing)
 func (b *Bot) SetUserChatID(chatID int64) {
-	b.userChatID = chatID
-	log.Printf("User chat ID set manually: %d", chatID)
+	b.userChatID.Store(chatID)
+	slog.Info("User chat ID set manually", "chat_id", chatID)
 }
 
-// SetWebhook configura el webhook de Telegram
-func (b *Bot) SetWebhook(webhookURL string) error {
-	webhookConfig, err := tgbotapi.NewWebhook(webhookURL)
-	if err != nil {
-		return fmt.Errorf("failed to create webhook config: %w", err)
-	}
-
-	_, err = b.api.Request(webhookConfig)
-	if err != nil {
-		return fmt.Errorf("failed to set webhook: %w", err)
+// SetWebhook configura el webhook de Telegram. Si opts.SecretToken no está vacío,
+// el handler del webhook rechaza las peticiones que no lo incluyan.
+func (b *Bot) SetWebhook(opts WebhookOptions) error {
+	if err := ConfigureWebhook(b.api, opts); err != nil {
+		return err
 	}
+	b.webhookSecret = opts.SecretToken
 
-	info, err := b.api.GetWebhookInfo()
+	status, err := FetchWebhookStatus(b.api)
 	if err != nil {
-		return fmt.Errorf("failed to get webhook info: %w", err)
+		return err
 	}
 
-	log.Printf("Webhook set successfully!")
-	log.Printf("URL: %s", info.URL)
-	log.Printf("Pending updates: %d", info.PendingUpdateCount)
+	slog.Info("Webhook set successfully", "url", status.URL, "pending_updates", status.PendingUpdateCount)
 
 	return nil
 }
@@ -412,57 +969,62 @@ func (b *Bot) SetWebhook(webhookURL string) error {
 // GetWebhookHandler retorna un http.Handler para procesar actualizaciones del webhook
 func (b *Bot) GetWebhookHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Println("📨 Webhook request received")
-		log.Printf("Method: %s | Path: %s", r.Method, r.URL.Path)
-		log.Printf("Remote Address: %s", r.RemoteAddr)
+		logger := slog.Default().With("method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+
+		if b.webhookSecret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(b.webhookSecret)) != 1 {
+			logger.Warn("Rejected webhook request with invalid secret token")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 
 		// Parsear el update de Telegram
 		var update tgbotapi.Update
 		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-			log.Printf("❌ Error decoding update: %v", err)
+			logger.Warn("Error decoding webhook update", "error", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		// Log del update completo en JSON
-		updateJSON, _ := json.MarshalIndent(update, "", "  ")
-		log.Printf("📦 Full Update JSON:\n%s", string(updateJSON))
+		logger = logger.With("update_id", update.UpdateID)
+		logUpdate(logger, update)
+
+		// Procesar el update
+		b.processUpdate(logging.WithLogger(r.Context(), logger), update)
 
-		// Log de información específica
-		log.Printf("Update ID: %d", update.UpdateID)
+		w.WriteHeader(http.StatusOK)
+	}
+}
 
-		if update.Message != nil {
-			log.Printf("📩 Message received:")
-			log.Printf("  From: %s (@%s) [ID: %d]",
-				update.Message.From.FirstName,
-				update.Message.From.UserName,
-				update.Message.From.ID)
-			log.Printf("  Chat ID: %d", update.Message.Chat.ID)
-			log.Printf("  Text: %s", update.Message.Text)
-			if update.Message.IsCommand() {
-				log.Printf("  Command: /%s", update.Message.Command())
-			}
-		}
+// logUpdate registra el contenido de un update. Nombres y texto se redactan salvo en modo debug.
+func logUpdate(logger *slog.Logger, update tgbotapi.Update) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
 
-		if update.CallbackQuery != nil {
-			log.Printf("🔘 Callback Query received:")
-			log.Printf("  From: %s (@%s) [ID: %d]",
-				update.CallbackQuery.From.FirstName,
-				update.CallbackQuery.From.UserName,
-				update.CallbackQuery.From.ID)
-			log.Printf("  Data: %s", update.CallbackQuery.Data)
-		}
+	if logging.Debug() {
+		updateJSON, _ := json.Marshal(update)
+		logger.Debug("Webhook update payload", "update", string(updateJSON))
+	}
 
-		// Procesar el update
-		if update.Message != nil {
-			b.handleMessage(update.Message)
-		} else if update.CallbackQuery != nil {
-			b.handleCallback(update.CallbackQuery)
+	if update.Message != nil {
+		attrs := []any{
+			"from_id", update.Message.From.ID,
+			"from_name", logging.Redact(update.Message.From.FirstName),
+			"from_username", logging.Redact(update.Message.From.UserName),
+			"text", logging.Redact(update.Message.Text),
 		}
+		if update.Message.IsCommand() {
+			attrs = append(attrs, "command", update.Message.Command())
+		}
+		logger.Debug("Message received", attrs...)
+	}
 
-		log.Println("✅ Update processed successfully")
-		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		w.WriteHeader(http.StatusOK)
+	if update.CallbackQuery != nil {
+		logger.Debug("Callback query received",
+			"from_id", update.CallbackQuery.From.ID,
+			"from_name", logging.Redact(update.CallbackQuery.From.FirstName),
+			"from_username", logging.Redact(update.CallbackQuery.From.UserName),
+			"data", update.CallbackQuery.Data,
+		)
 	}
 }
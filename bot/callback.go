@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxCallbackData es el límite de Telegram para el callback_data de un botón
+const maxCallbackData = 64
+
+// macSize es la cantidad de bytes del HMAC que viajan en el botón
+const macSize = 8
+
+// Motivos de rechazo de un callback, usados en logs y métricas
+var (
+	errCallbackMalformed = errors.New("malformed")
+	errCallbackSignature = errors.New("signature")
+	errCallbackOwner     = errors.New("owner")
+)
+
+// callbackData es el contenido de un botón inline: type_action_habitID_owner_mac
+// (ej: plan_yes_1_kf12oi_9c2d4e0f1a7b3c58). owner es el usuario al que se envió el
+// botón, en base 36, y mac autentica todo lo anterior.
+type callbackData struct {
+	Type    string // plan, review, today, remind o resolve
+	Action  string // en plan y review, yes o no y la fecha de la pregunta (yes20240306); en today, done, skip o undo; en remind, done, skip, s15 o s60; en resolve, y o n y la fecha (y20240306)
+	HabitID int
+	Owner   int64
+}
+
+// callbackKey deriva la clave de firma de los botones del token del bot, para
+// que los botones ya enviados sigan siendo válidos después de reiniciar
+func callbackKey(botToken string) []byte {
+	mac := hmac.New(sha256.New, []byte(botToken))
+	mac.Write([]byte("habittracker callback data"))
+	return mac.Sum(nil)
+}
+
+// encode firma el contenido del botón
+func (c callbackData) encode(key []byte) (string, error) {
+	payload := fmt.Sprintf("%s_%s_%d_%s", c.Type, c.Action, c.HabitID, strconv.FormatInt(c.Owner, 36))
+	data := payload + "_" + callbackMAC(key, payload)
+	if len(data) > maxCallbackData {
+		return "", fmt.Errorf("callback data too long (%d bytes)", len(data))
+	}
+	return data, nil
+}
+
+// parseCallbackData verifica la firma de un botón y que lo presione su dueño
+func parseCallbackData(key []byte, data string, from int64) (callbackData, error) {
+	i := strings.LastIndexByte(data, '_')
+	if i == -1 {
+		return callbackData{}, errCallbackMalformed
+	}
+	payload, mac := data[:i], data[i+1:]
+	if !hmac.Equal([]byte(mac), []byte(callbackMAC(key, payload))) {
+		return callbackData{}, errCallbackSignature
+	}
+
+	parts := strings.Split(payload, "_")
+	if len(parts) != 4 {
+		return callbackData{}, errCallbackMalformed
+	}
+	habitID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return callbackData{}, errCallbackMalformed
+	}
+	owner, err := strconv.ParseInt(parts[3], 36, 64)
+	if err != nil {
+		return callbackData{}, errCallbackMalformed
+	}
+	if owner != from {
+		return callbackData{}, errCallbackOwner
+	}
+
+	return callbackData{Type: parts[0], Action: parts[1], HabitID: habitID, Owner: owner}, nil
+}
+
+func callbackMAC(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)[:macSize])
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCallbackData prueba la firma de los botones, el dueño y el límite de tamaño
+func TestCallbackData(t *testing.T) {
+	key := callbackKey("123:token")
+	const owner = 987654321
+
+	data, err := callbackData{Type: "review", Action: "yes", HabitID: 7, Owner: owner}.encode(key)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+
+	got, err := parseCallbackData(key, data, owner)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", data, err)
+	}
+	if got.Type != "review" || got.Action != "yes" || got.HabitID != 7 || got.Owner != owner {
+		t.Errorf("Unexpected callback data: %+v", got)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		from int64
+		want error
+	}{
+		{"legacy unsigned", "review_yes_7", owner, errCallbackSignature},
+		{"no separator", "garbage", owner, errCallbackMalformed},
+		{"forged habit", strings.Replace(data, "_7_", "_8_", 1), owner, errCallbackSignature},
+		{"other user", data, owner + 1, errCallbackOwner},
+		{"other key", data, owner, errCallbackSignature},
+	}
+	for _, tt := range tests {
+		k := key
+		if tt.name == "other key" {
+			k = callbackKey("456:other")
+		}
+		if _, err := parseCallbackData(k, tt.data, tt.from); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// El peor caso tiene que entrar en los 64 bytes de Telegram
+	long, err := callbackData{Type: "review", Action: "yes20240306", HabitID: math.MaxInt32, Owner: math.MinInt64}.encode(key)
+	if err != nil || len(long) > maxCallbackData {
+		t.Errorf("Expected worst case to fit in %d bytes, got %d (%v)", maxCallbackData, len(long), err)
+	}
+}
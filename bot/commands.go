@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"habittracker/i18n"
+	"habittracker/metrics"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// command es un comando del bot. La lista de comandos define el despacho, la
+// ayuda de /help y el menú de Telegram. Cada comando tiene en el catálogo una
+// descripción corta para el menú (command.<nombre>) y sus líneas de ayuda
+// (help.<nombre>).
+type command struct {
+	name    string
+	handler func(b *Bot, ctx context.Context, message *tgbotapi.Message)
+	admin   bool // Solo aparece en el menú y en la ayuda de los administradores
+	owner   bool // Usa los hábitos: en modo privado, solo para sus dueños
+	hidden  bool // No aparece en el menú ni en la ayuda
+}
+
+// visible indica si el comando aparece en la ayuda y en el menú de un usuario
+func (c command) visible(admin, owner bool) bool {
+	return !c.hidden && (admin || !c.admin) && (owner || !c.owner)
+}
+
+// commands son los comandos del bot, en el orden del menú y de la ayuda. Se
+// inicializan en init porque /help recorre la lista.
+var commands []command
+
+// commandIndex son los comandos por nombre
+var commandIndex map[string]command
+
+func init() {
+	commands = []command{
+		{name: "start", handler: (*Bot).handleStart},
+		{name: "help", handler: (*Bot).handleHelp},
+		{name: "addhabit", handler: (*Bot).handleAddHabit, owner: true},
+		{name: "today", handler: (*Bot).handleToday, owner: true},
+		{name: "listhabits", handler: (*Bot).handleListHabits, owner: true},
+		{name: "deletehabit", handler: (*Bot).handleDeleteHabit, owner: true},
+		{name: "schedule", handler: (*Bot).handleSchedule, owner: true},
+		{name: "pause", handler: (*Bot).handlePause, owner: true},
+		{name: "resume", handler: (*Bot).handleResume, owner: true},
+		{name: "export", handler: (*Bot).handleExport, owner: true},
+		{name: "calendar", handler: (*Bot).handleCalendar, owner: true},
+		{name: "dashboard", handler: (*Bot).handleDashboard, owner: true},
+		{name: "apitoken", handler: (*Bot).handleAPIToken, owner: true},
+		{name: "language", handler: (*Bot).handleLanguage},
+		{name: "quiet", handler: (*Bot).handleQuiet},
+		{name: "dnd", handler: (*Bot).handleDoNotDisturb},
+		{name: "cancel", handler: (*Bot).handleCancel},
+		{name: "skip", handler: (*Bot).handleSkip, hidden: true},
+		{name: "backup", handler: (*Bot).handleBackup, admin: true},
+		{name: "users", handler: (*Bot).handleUsers, admin: true},
+		{name: "approve", handler: (*Bot).handleApprove, admin: true},
+		{name: "ban", handler: (*Bot).handleBan, admin: true},
+	}
+
+	commandIndex = make(map[string]command, len(commands))
+	for _, c := range commands {
+		commandIndex[c.name] = c
+	}
+}
+
+// dispatchCommand ejecuta el comando de un mensaje
+func (b *Bot) dispatchCommand(ctx context.Context, message *tgbotapi.Message) {
+	c, ok := commandIndex[message.Command()]
+	if !ok {
+		metrics.CommandsTotal.Inc("unknown")
+		b.send(tgbotapi.NewMessage(message.Chat.ID, i18n.FromContext(ctx).T("unknown_command")))
+		return
+	}
+	metrics.CommandsTotal.Inc(c.name)
+	if c.owner && !b.requireOwner(ctx, message) {
+		return
+	}
+	c.handler(b, ctx, message)
+}
+
+// isAdmin indica si un usuario, o el chat desde el que escribe, administra el
+// bot. Es el único control de los comandos de administración, de la ayuda y
+// del menú, para no mostrar comandos que después se rechazan.
+func (b *Bot) isAdmin(userID, chatID int64) bool {
+	if b.users != nil && b.users.IsAdmin(userID) {
+		return true
+	}
+	return b.adminChatID != 0 && chatID == b.adminChatID
+}
+
+// helpText arma la ayuda de /help con los comandos visibles para el usuario
+func helpText(tr *i18n.Localizer, admin, owner bool) string {
+	var text strings.Builder
+	text.WriteString(tr.T("help.title") + "\n\n")
+	for _, c := range commands {
+		if !c.visible(admin, owner) {
+			continue
+		}
+		text.WriteString(tr.T("help."+c.name) + "\n")
+	}
+	// El pie explica cómo importar y agregar hábitos
+	if owner {
+		text.WriteString("\n" + tr.T("help.footer"))
+	}
+	return text.String()
+}
+
+// menuScope es un ámbito de Telegram con los permisos de sus comandos
+type menuScope struct {
+	scope tgbotapi.BotCommandScope
+	admin bool
+	owner bool
+}
+
+// commandMenus arma los menús de comandos de Telegram: uno general y uno para
+// el chat privado de cada dueño de los hábitos y de cada administrador, en
+// cada idioma y en el idioma por defecto para los usuarios sin uno disponible.
+// En modo privado el menú general no incluye los comandos de los hábitos.
+func (b *Bot) commandMenus() []tgbotapi.SetMyCommandsConfig {
+	scopes := []menuScope{{scope: tgbotapi.NewBotCommandScopeDefault(), owner: b.users == nil}}
+
+	var chats []int64
+	if b.users != nil {
+		chats = b.users.Owners()
+	}
+	if b.adminChatID != 0 && !slices.Contains(chats, b.adminChatID) {
+		chats = append(chats, b.adminChatID)
+	}
+	for _, chatID := range chats {
+		scopes = append(scopes, menuScope{
+			scope: tgbotapi.NewBotCommandScopeChat(chatID),
+			admin: b.isAdmin(chatID, chatID),
+			owner: b.ownsHabits(chatID),
+		})
+	}
+
+	languages := append([]string{""}, i18n.Languages()...)
+	var menus []tgbotapi.SetMyCommandsConfig
+	for _, s := range scopes {
+		for _, lang := range languages {
+			tr := i18n.Get(lang)
+			var list []tgbotapi.BotCommand
+			for _, c := range commands {
+				if !c.visible(s.admin, s.owner) {
+					continue
+				}
+				list = append(list, tgbotapi.BotCommand{Command: c.name, Description: tr.T("command." + c.name)})
+			}
+			menus = append(menus, tgbotapi.NewSetMyCommandsWithScopeAndLanguage(s.scope, lang, list...))
+		}
+	}
+	return menus
+}
+
+// RegisterCommands publica los menús de comandos en Telegram
+func (b *Bot) RegisterCommands() error {
+	for _, menu := range b.commandMenus() {
+		if _, err := b.api.Request(menu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
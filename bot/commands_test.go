@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"habittracker/i18n"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var commandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// TestCommandRegistry prueba que cada comando tiene un nombre válido para
+// Telegram, su descripción para el menú y su ayuda en todos los idiomas
+func TestCommandRegistry(t *testing.T) {
+	if len(commandIndex) != len(commands) {
+		t.Errorf("Duplicated command names: %d commands, %d names", len(commands), len(commandIndex))
+	}
+
+	for _, c := range commands {
+		if !commandName.MatchString(c.name) {
+			t.Errorf("Invalid command name %q", c.name)
+		}
+		if c.handler == nil {
+			t.Errorf("/%s has no handler", c.name)
+		}
+		if c.hidden {
+			continue
+		}
+		for _, lang := range i18n.Languages() {
+			tr := i18n.Get(lang)
+			desc := tr.T("command." + c.name)
+			if desc == "command."+c.name || utf8.RuneCountInString(desc) > 256 {
+				t.Errorf("%s: invalid menu description for /%s: %q", lang, c.name, desc)
+			}
+			if help := tr.T("help." + c.name); !strings.HasPrefix(help, "/"+c.name) {
+				t.Errorf("%s: help for /%s should start with the command: %q", lang, c.name, help)
+			}
+		}
+	}
+
+	tr := i18n.Get("en")
+	if help := helpText(tr, false, true); strings.Contains(help, "/ban") || strings.Contains(help, "/skip") || !strings.Contains(help, "/addhabit") {
+		t.Errorf("Unexpected help for users:\n%s", help)
+	}
+	if help := helpText(tr, false, false); strings.Contains(help, "/addhabit") || !strings.Contains(help, "/language") {
+		t.Errorf("Unexpected help for guests:\n%s", help)
+	}
+	if help := helpText(tr, true, true); !strings.Contains(help, "/ban") || !strings.Contains(help, "/backup") {
+		t.Errorf("Expected admin commands in admin help:\n%s", help)
+	}
+}
+
+// TestCommandMenus prueba que los comandos de administración solo se publican
+// para los chats de los administradores y los de los hábitos para sus dueños,
+// en cada idioma
+func TestCommandMenus(t *testing.T) {
+	b := newTestAccessBot(t)
+	b.adminChatID = -100
+
+	menus := b.commandMenus()
+	languages := len(i18n.Languages()) + 1
+	if len(menus) != 4*languages {
+		t.Fatalf("Expected %d menus (default, admin %d, user %d and chat %d), got %d", 4*languages, testAdminID, testAllowedID, b.adminChatID, len(menus))
+	}
+
+	for _, menu := range menus {
+		names := make(map[string]bool)
+		for _, c := range menu.Commands {
+			names[c.Command] = true
+		}
+		if names["skip"] {
+			t.Errorf("Hidden command in menu %+v", menu.Scope)
+		}
+
+		switch menu.Scope.Type {
+		case "default":
+			if names["ban"] || names["addhabit"] || !names["start"] {
+				t.Errorf("Unexpected default menu (%q): %v", menu.LanguageCode, names)
+			}
+		case "chat":
+			switch menu.Scope.ChatID {
+			case testAdminID:
+				if !names["ban"] || !names["addhabit"] {
+					t.Errorf("Unexpected admin menu (%q): %v", menu.LanguageCode, names)
+				}
+			case testAllowedID:
+				if names["ban"] || !names["addhabit"] {
+					t.Errorf("Unexpected user menu (%q): %v", menu.LanguageCode, names)
+				}
+			case b.adminChatID:
+				if !names["ban"] || !names["start"] {
+					t.Errorf("Unexpected admin chat menu (%q): %v", menu.LanguageCode, names)
+				}
+			default:
+				t.Errorf("Unexpected menu for chat %d", menu.Scope.ChatID)
+			}
+		default:
+			t.Errorf("Unexpected scope %q", menu.Scope.Type)
+		}
+	}
+
+	if got := menus[1].Commands[0].Description; got != i18n.Get(menus[1].LanguageCode).T("command.start") {
+		t.Errorf("Expected descriptions in %q, got %q", menus[1].LanguageCode, got)
+	}
+}
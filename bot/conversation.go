@@ -0,0 +1,279 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/storage"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Las conversaciones permiten que un comando haga varias preguntas seguidas
+// (asistentes). El estado se guarda por chat para sobrevivir a los reinicios,
+// vence si el usuario deja de responder y se termina con /cancel. Un asistente
+// nuevo reemplaza al que estuviera en curso en el chat.
+
+// DefaultConversationTimeout es el tiempo sin respuesta tras el cual vence una conversación
+const DefaultConversationTimeout = 10 * time.Minute
+
+// ErrConversationExpired indica que la conversación del chat venció
+var ErrConversationExpired = errors.New("conversation expired")
+
+// Conversation es un asistente en curso en un chat
+type Conversation struct {
+	Flow      string            `json:"flow"`
+	Step      int               `json:"step"`
+	UserID    int64             `json:"user_id"` // Solo responde quien empezó la conversación
+	Data      map[string]string `json:"data,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Conversations guarda las conversaciones en curso en un archivo JSON, por chat
+type Conversations struct {
+	chats   map[int64]Conversation
+	file    string
+	timeout time.Duration
+	mu      sync.Mutex
+}
+
+// NewConversations crea el almacén y carga las conversaciones existentes
+func NewConversations(file string, timeout time.Duration) (*Conversations, error) {
+	if timeout <= 0 {
+		timeout = DefaultConversationTimeout
+	}
+	c := &Conversations{
+		chats:   make(map[int64]Conversation),
+		file:    file,
+		timeout: timeout,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get devuelve la conversación en curso de un chat, o nil si no tiene. Las
+// vencidas se borran y devuelven ErrConversationExpired.
+func (c *Conversations) Get(chatID int64, now time.Time) (*Conversation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conv, ok := c.chats[chatID]
+	if !ok {
+		return nil, nil
+	}
+	if now.Sub(conv.UpdatedAt) > c.timeout {
+		delete(c.chats, chatID)
+		if err := c.save(); err != nil {
+			return nil, err
+		}
+		return nil, ErrConversationExpired
+	}
+	return &conv, nil
+}
+
+// Set guarda la conversación de un chat
+func (c *Conversations) Set(chatID int64, conv Conversation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chats[chatID] = conv
+	return c.save()
+}
+
+// Delete termina la conversación de un chat. Devuelve false si no había ninguna.
+func (c *Conversations) Delete(chatID int64) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.chats[chatID]; !ok {
+		return false, nil
+	}
+	delete(c.chats, chatID)
+	return true, c.save()
+}
+
+// load carga las conversaciones desde el archivo
+func (c *Conversations) load() error {
+	data, err := storage.ReadFile(c.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &c.chats)
+}
+
+// save guarda las conversaciones en el archivo
+func (c *Conversations) save() error {
+	data, err := json.MarshalIndent(c.chats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(c.file, data, 0600)
+}
+
+// SetConversations habilita los asistentes de varios pasos
+func (b *Bot) SetConversations(c *Conversations) {
+	b.conversations = c
+}
+
+// flow es un asistente: una serie de preguntas y la acción con las respuestas
+type flow struct {
+	steps  []flowStep
+	finish func(b *Bot, ctx context.Context, message *tgbotapi.Message, data map[string]string)
+}
+
+// flowStep es una pregunta de un asistente
+type flowStep struct {
+	key      string                            // Clave de la respuesta en Conversation.Data
+	prompt   string                            // Mensaje del catálogo con la pregunta
+	invalid  string                            // Mensaje del catálogo si parse falla
+	optional bool                              // Se puede saltar con /skip
+	parse    func(text string) (string, error) // Valida y normaliza la respuesta (nil: se acepta tal cual)
+}
+
+// startConversation empieza un asistente en el chat del mensaje y hace la
+// primera pregunta. Devuelve false si los asistentes no están habilitados.
+func (b *Bot) startConversation(ctx context.Context, message *tgbotapi.Message, name string) bool {
+	if b.conversations == nil || message.From == nil {
+		return false
+	}
+
+	conv := Conversation{
+		Flow:      name,
+		UserID:    message.From.ID,
+		Data:      make(map[string]string),
+		UpdatedAt: time.Now(),
+	}
+	if err := b.conversations.Set(message.Chat.ID, conv); err != nil {
+		logging.FromContext(ctx).Error("Error saving conversation", "flow", name, "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, i18n.FromContext(ctx).T("error", err)))
+		return true
+	}
+	logging.FromContext(ctx).Info("Conversation started", "flow", name)
+	b.askStep(ctx, message.Chat.ID, flows[name].steps[0])
+	return true
+}
+
+// continueConversation procesa una respuesta (texto o /skip) a la pregunta en
+// curso. Devuelve false si el chat no tiene una conversación del remitente.
+func (b *Bot) continueConversation(ctx context.Context, message *tgbotapi.Message) bool {
+	if b.conversations == nil || message.From == nil {
+		return false
+	}
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+	chatID := message.Chat.ID
+
+	conv, err := b.conversations.Get(chatID, time.Now())
+	if errors.Is(err, ErrConversationExpired) {
+		logger.Info("Conversation expired")
+		b.send(tgbotapi.NewMessage(chatID, tr.T("conversation.expired")))
+		return true
+	}
+	if err != nil {
+		logger.Error("Error loading conversation", "error", err)
+		return false
+	}
+	if conv == nil || conv.UserID != message.From.ID {
+		return false
+	}
+
+	f, ok := flows[conv.Flow]
+	if !ok || conv.Step >= len(f.steps) {
+		logger.Warn("Dropping conversation with unknown flow", "flow", conv.Flow, "step", conv.Step)
+		b.conversations.Delete(chatID)
+		return false
+	}
+	step := f.steps[conv.Step]
+
+	if message.IsCommand() {
+		// El único comando que responde una pregunta es /skip
+		if !step.optional {
+			b.send(tgbotapi.NewMessage(chatID, tr.T("conversation.not_optional")))
+			return true
+		}
+	} else {
+		value := strings.TrimSpace(message.Text)
+		if step.parse != nil {
+			if value, err = step.parse(value); err != nil {
+				b.send(tgbotapi.NewMessage(chatID, tr.T(step.invalid)))
+				return true
+			}
+		}
+		if conv.Data == nil {
+			conv.Data = make(map[string]string)
+		}
+		conv.Data[step.key] = value
+	}
+
+	conv.Step++
+	if conv.Step == len(f.steps) {
+		if _, err := b.conversations.Delete(chatID); err != nil {
+			logger.Error("Error deleting conversation", "error", err)
+		}
+		logger.Info("Conversation finished", "flow", conv.Flow)
+		f.finish(b, ctx, message, conv.Data)
+		return true
+	}
+
+	conv.UpdatedAt = time.Now()
+	if err := b.conversations.Set(chatID, *conv); err != nil {
+		logger.Error("Error saving conversation", "error", err)
+		b.send(tgbotapi.NewMessage(chatID, tr.T("error", err)))
+		return true
+	}
+	b.askStep(ctx, chatID, f.steps[conv.Step])
+	return true
+}
+
+// askStep envía una pregunta con la ayuda para saltarla o cancelar
+func (b *Bot) askStep(ctx context.Context, chatID int64, step flowStep) {
+	tr := i18n.FromContext(ctx)
+	hint := tr.T("conversation.hint")
+	if step.optional {
+		hint = tr.T("conversation.hint_optional")
+	}
+	b.send(tgbotapi.NewMessage(chatID, tr.T(step.prompt)+"\n"+hint))
+}
+
+// handleSkip maneja el comando /skip fuera de una pregunta que se pueda saltar
+func (b *Bot) handleSkip(ctx context.Context, message *tgbotapi.Message) {
+	if !b.continueConversation(ctx, message) {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, i18n.FromContext(ctx).T("conversation.none")))
+	}
+}
+
+// handleCancel maneja el comando /cancel
+func (b *Bot) handleCancel(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if b.conversations == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("conversation.none")))
+		return
+	}
+
+	deleted, err := b.conversations.Delete(message.Chat.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error deleting conversation", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+		return
+	}
+	if !deleted {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("conversation.none")))
+		return
+	}
+	logging.FromContext(ctx).Info("Conversation cancelled")
+	b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("conversation.cancelled")))
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAddHabitWizard prueba el asistente de /addhabit: respuestas inválidas,
+// preguntas saltadas, el estado guardado entre reinicios y /cancel
+func TestAddHabitWizard(t *testing.T) {
+	b := newTestAccessBot(t)
+	file := filepath.Join(t.TempDir(), "conversations.json")
+	conversations, err := NewConversations(file, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create conversations: %v", err)
+	}
+	b.SetConversations(conversations)
+	ctx := context.Background()
+
+	say := func(text string) string {
+		b.processUpdate(ctx, privateMessage(testAllowedID, text))
+		var replies []string
+		for _, msg := range takeSent(b) {
+			replies = append(replies, msg.Text)
+		}
+		return strings.Join(replies, "\n")
+	}
+
+	if got := say("/addhabit"); !strings.Contains(got, "¿Cómo se llama el hábito?") {
+		t.Fatalf("Expected name question, got %q", got)
+	}
+	if got := say("/skip"); !strings.Contains(got, "no se puede saltar") {
+		t.Errorf("Expected name not to be skippable, got %q", got)
+	}
+	say("Leer")
+	if got := say("/skip"); !strings.Contains(got, "¿Qué días?") {
+		t.Errorf("Expected days question after skipping the description, got %q", got)
+	}
+
+	// Otro usuario no puede responder por el dueño de la conversación
+	b.processUpdate(ctx, privateMessage(testAdminID, "lun"))
+	takeSent(b)
+
+	// El estado sobrevive a un reinicio
+	conversations, err = NewConversations(file, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to reload conversations: %v", err)
+	}
+	b.SetConversations(conversations)
+
+	if got := say("lunes,funday"); !strings.Contains(got, "Días inválidos") {
+		t.Errorf("Expected invalid days, got %q", got)
+	}
+	say("lun,mie")
+	if got := say("25:00"); !strings.Contains(got, "Hora inválida") {
+		t.Errorf("Expected invalid time, got %q", got)
+	}
+	got := say("07:30")
+	for _, want := range []string{"Nombre: Leer", "Días: lun,mie", "Recordatorio: 07:30"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+
+	habits := b.habitManager.GetHabits()
+	if len(habits) != 1 {
+		t.Fatalf("Expected 1 habit, got %d", len(habits))
+	}
+	h := habits[0]
+	if h.Name != "Leer" || h.Description != "" || h.ReminderTime != "07:30" || !slices.Equal(h.Weekdays, []time.Weekday{time.Monday, time.Wednesday}) {
+		t.Errorf("Unexpected habit: %+v", h)
+	}
+
+	// Terminado el asistente, los mensajes sueltos se ignoran
+	if got := say("hola"); got != "" {
+		t.Errorf("Expected no reply outside a conversation, got %q", got)
+	}
+
+	say("/addhabit")
+	if got := say("/cancel"); !strings.Contains(got, "Cancelado") {
+		t.Errorf("Expected cancellation, got %q", got)
+	}
+	if got := say("/cancel"); !strings.Contains(got, "No hay ninguna conversación") {
+		t.Errorf("Expected nothing to cancel, got %q", got)
+	}
+	if len(b.habitManager.GetHabits()) != 1 {
+		t.Error("Expected cancelled wizard not to add a habit")
+	}
+}
+
+// TestConversationTimeout prueba que las conversaciones vencen sin respuesta
+func TestConversationTimeout(t *testing.T) {
+	c, err := NewConversations(filepath.Join(t.TempDir(), "conversations.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create conversations: %v", err)
+	}
+	now := time.Now()
+	c.Set(1, Conversation{Flow: "addhabit", UserID: 1, UpdatedAt: now})
+
+	if conv, err := c.Get(1, now.Add(30*time.Second)); err != nil || conv == nil {
+		t.Fatalf("Expected active conversation, got %v, %v", conv, err)
+	}
+	if _, err := c.Get(1, now.Add(2*time.Minute)); err != ErrConversationExpired {
+		t.Errorf("Expected expired conversation, got %v", err)
+	}
+	if conv, err := c.Get(1, now); conv != nil || err != nil {
+		t.Errorf("Expected expired conversation to be deleted, got %v, %v", conv, err)
+	}
+}
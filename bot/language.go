@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/settings"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SetSettings habilita las preferencias por usuario, como el idioma elegido con /language
+func (b *Bot) SetSettings(store *settings.Store) {
+	b.settings = store
+}
+
+// localizer elige el idioma de un update: el elegido con /language, el de
+// Telegram o el idioma por defecto
+func (b *Bot) localizer(from *tgbotapi.User) *i18n.Localizer {
+	if from == nil {
+		return i18n.Get("")
+	}
+	if b.settings != nil {
+		if lang := b.settings.Get(from.ID).Language; lang != "" {
+			return i18n.Get(lang)
+		}
+	}
+	if lang, ok := i18n.Match(from.LanguageCode); ok {
+		return i18n.Get(lang)
+	}
+	return i18n.Get("")
+}
+
+// userLocalizer elige el idioma de los mensajes que no responden a un update
+// (notificaciones programadas, avisos a otros usuarios)
+func (b *Bot) userLocalizer(userID int64) *i18n.Localizer {
+	if b.settings == nil {
+		return i18n.Get("")
+	}
+	s := b.settings.Get(userID)
+	if s.Language != "" {
+		return i18n.Get(s.Language)
+	}
+	return i18n.Get(s.DetectedLanguage)
+}
+
+// rememberUser guarda el idioma de Telegram y el nombre del usuario para usarlos
+// en las notificaciones programadas
+func (b *Bot) rememberUser(ctx context.Context, from *tgbotapi.User) {
+	if b.settings == nil || from == nil {
+		return
+	}
+	err := b.settings.Update(from.ID, func(u *settings.User) {
+		if lang, ok := i18n.Match(from.LanguageCode); ok {
+			u.DetectedLanguage = lang
+		}
+		u.Name = from.FirstName
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error saving user settings", "error", err)
+	}
+}
+
+// handleLanguage maneja el comando /language [código|auto]
+func (b *Bot) handleLanguage(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if b.settings == nil || message.From == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("language.disabled")))
+		return
+	}
+
+	var available []string
+	for _, lang := range i18n.Languages() {
+		available = append(available, fmt.Sprintf("%s (%s)", lang, i18n.Get(lang).T("language.name")))
+	}
+	list := strings.Join(available, ", ")
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if arg == "" {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("language.current", tr.T("language.name"), list)))
+		return
+	}
+
+	lang, ok := i18n.Match(arg)
+	if arg == "auto" {
+		lang, ok = "", true
+	}
+	if !ok {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("language.invalid", arg, list)))
+		return
+	}
+
+	if err := b.settings.Update(message.From.ID, func(u *settings.User) { u.Language = lang }); err != nil {
+		logging.FromContext(ctx).Error("Error saving language", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("language.error")))
+		return
+	}
+	logging.FromContext(ctx).Info("Language changed", "language", lang)
+
+	if lang == "" {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, b.localizer(message.From).T("language.auto")))
+		return
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, i18n.Get(lang).T("language.changed")))
+}
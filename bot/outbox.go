@@ -0,0 +1,408 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"habittracker/metrics"
+	"habittracker/storage"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Límites de Telegram: ~30 mensajes por segundo en total y 1 por segundo por chat
+const (
+	defaultGlobalInterval  = time.Second / 30
+	defaultPerChatInterval = time.Second
+	defaultMaxAttempts     = 8
+	defaultBaseBackoff     = 2 * time.Second
+	defaultMaxBackoff      = 5 * time.Minute
+)
+
+// sender es la parte de tgbotapi.BotAPI que necesita la cola de salida
+type sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// OutboundMessage es un mensaje pendiente de envío
+type OutboundMessage struct {
+	ID        int64                          `json:"id"`
+	ChatID    int64                          `json:"chat_id"`
+	Text      string                         `json:"text"`
+	ParseMode string                         `json:"parse_mode,omitempty"`
+	Keyboard  *tgbotapi.InlineKeyboardMarkup `json:"keyboard,omitempty"`
+	Attempts  int                            `json:"attempts"`
+	NotBefore time.Time                      `json:"not_before"`
+	Deferred  bool                           `json:"deferred,omitempty"` // Diferido por el horario de silencio
+	CreatedAt time.Time                      `json:"created_at"`
+}
+
+// Outbox es una cola de mensajes salientes con rate limiting y reintentos.
+// Los mensajes no entregados se guardan en disco para sobrevivir reinicios.
+type Outbox struct {
+	api    sender
+	file   string
+	queue  []OutboundMessage
+	nextID int64
+
+	GlobalInterval  time.Duration
+	PerChatInterval time.Duration
+	MaxAttempts     int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+
+	globalNext  time.Time
+	chatNext    map[int64]time.Time
+	pausedUntil time.Time // Espera de Telegram ante un 429, que vale para todo el bot
+
+	mu      sync.Mutex
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// NewOutbox crea una cola de salida y carga los mensajes pendientes del archivo
+func NewOutbox(api sender, file string) *Outbox {
+	o := &Outbox{
+		api:             api,
+		file:            file,
+		queue:           []OutboundMessage{},
+		nextID:          1,
+		GlobalInterval:  defaultGlobalInterval,
+		PerChatInterval: defaultPerChatInterval,
+		MaxAttempts:     defaultMaxAttempts,
+		BaseBackoff:     defaultBaseBackoff,
+		MaxBackoff:      defaultMaxBackoff,
+		chatNext:        make(map[int64]time.Time),
+		wake:            make(chan struct{}, 1),
+	}
+	if err := o.load(); err != nil {
+		slog.Error("Error loading outbox", "file", file, "error", err)
+	}
+	return o
+}
+
+// Enqueue agrega un mensaje a la cola y lo persiste
+func (o *Outbox) Enqueue(msg OutboundMessage) error {
+	o.mu.Lock()
+	msg.ID = o.nextID
+	o.nextID++
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	o.queue = append(o.queue, msg)
+	err := o.save()
+	o.mu.Unlock()
+
+	o.notify()
+	return err
+}
+
+// Pending devuelve la cantidad de mensajes sin entregar
+func (o *Outbox) Pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.queue)
+}
+
+// WaitEmpty espera hasta que la cola se vacíe o venza el timeout
+func (o *Outbox) WaitEmpty(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if o.Pending() == 0 {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return o.Pending() == 0
+}
+
+// Start inicia el worker que entrega los mensajes
+func (o *Outbox) Start() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.running {
+		return
+	}
+	o.running = true
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	go o.run(o.stop, o.done)
+}
+
+// Stop detiene el worker; los mensajes pendientes quedan guardados en disco
+func (o *Outbox) Stop() {
+	o.mu.Lock()
+	if !o.running {
+		o.mu.Unlock()
+		return
+	}
+	o.running = false
+	close(o.stop)
+	done := o.done
+	o.mu.Unlock()
+	<-done
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) run(stop, done chan struct{}) {
+	defer close(done)
+	for {
+		msg, wait, ok := o.next(time.Now())
+		if ok {
+			o.deliver(msg)
+			continue
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-o.wake:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// next devuelve el primer mensaje que puede enviarse ahora respetando los límites.
+// Si no hay ninguno, devuelve cuánto esperar (0 si la cola está vacía).
+func (o *Outbox) next(now time.Time) (OutboundMessage, time.Duration, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.queue) == 0 {
+		return OutboundMessage{}, 0, false
+	}
+	if o.pausedUntil.After(now) {
+		return OutboundMessage{}, o.pausedUntil.Sub(now), false
+	}
+
+	var earliest time.Time
+	blocked := make(map[int64]bool)
+	for _, msg := range o.queue {
+		// Los mensajes diferidos no frenan a los demás mensajes del chat mientras esperan
+		if msg.Deferred && msg.NotBefore.After(now) {
+			if earliest.IsZero() || msg.NotBefore.Before(earliest) {
+				earliest = msg.NotBefore
+			}
+			continue
+		}
+
+		// Mantener el orden dentro de cada chat
+		if blocked[msg.ChatID] {
+			continue
+		}
+		blocked[msg.ChatID] = true
+
+		ready := msg.NotBefore
+		if chatReady := o.chatNext[msg.ChatID]; chatReady.After(ready) {
+			ready = chatReady
+		}
+		if o.globalNext.After(ready) {
+			ready = o.globalNext
+		}
+
+		if !ready.After(now) {
+			o.globalNext = now.Add(o.GlobalInterval)
+			o.chatNext[msg.ChatID] = now.Add(o.PerChatInterval)
+			return msg, 0, true
+		}
+
+		if earliest.IsZero() || ready.Before(earliest) {
+			earliest = ready
+		}
+	}
+
+	return OutboundMessage{}, earliest.Sub(now), false
+}
+
+// deliver envía un mensaje y actualiza la cola según el resultado
+func (o *Outbox) deliver(msg OutboundMessage) {
+	start := time.Now()
+	_, err := o.api.Send(msg.config())
+	if err != nil {
+		metrics.SendFailuresTotal.Inc(failureReason(err))
+		metrics.SendDuration.ObserveSince("error", start)
+	} else {
+		metrics.SendDuration.ObserveSince("ok", start)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	idx := -1
+	for i := range o.queue {
+		if o.queue[i].ID == msg.ID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return
+	}
+
+	if err == nil {
+		o.remove(idx)
+		return
+	}
+
+	msg.Attempts++
+	retryAfter, retryable := o.retryDelay(err, msg.Attempts)
+	if floodWait(err) {
+		// El límite de Telegram es del bot: frenar toda la cola, no solo este mensaje
+		o.pausedUntil = time.Now().Add(retryAfter)
+		slog.Warn("Telegram flood wait, pausing outbox", "retry_in", retryAfter)
+	}
+	if !retryable || msg.Attempts >= o.MaxAttempts {
+		slog.Error("Dropping outbound message", "message_id", msg.ID, "chat_id", msg.ChatID, "attempts", msg.Attempts, "error", err)
+		o.remove(idx)
+		return
+	}
+
+	slog.Warn("Error sending message, will retry", "message_id", msg.ID, "chat_id", msg.ChatID, "attempt", msg.Attempts, "retry_in", retryAfter, "error", err)
+	msg.NotBefore = time.Now().Add(retryAfter)
+	o.queue[idx] = msg
+	if err := o.save(); err != nil {
+		slog.Error("Error saving outbox", "error", err)
+	}
+}
+
+// retryDelay decide si un error es reintentable y cuánto esperar
+func (o *Outbox) retryDelay(err error, attempts int) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// Errores de red o de decodificación: reintentar con backoff
+		return o.backoff(attempts), true
+	}
+
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		if apiErr.RetryAfter > 0 {
+			return time.Duration(apiErr.RetryAfter) * time.Second, true
+		}
+		return o.backoff(attempts), true
+	case apiErr.Code >= 500:
+		return o.backoff(attempts), true
+	default:
+		return 0, false
+	}
+}
+
+// floodWait indica si el error es un 429 con retry_after
+func floodWait(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests && apiErr.RetryAfter > 0
+}
+
+// failureReason clasifica un error de envío para las métricas
+func failureReason(err error) string {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return "network"
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		return "rate_limited"
+	case apiErr.Code >= 500:
+		return "server_error"
+	default:
+		return "rejected"
+	}
+}
+
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= o.MaxBackoff {
+			return o.MaxBackoff
+		}
+	}
+	return d
+}
+
+// remove quita el mensaje en la posición idx y persiste la cola
+func (o *Outbox) remove(idx int) {
+	o.queue = append(o.queue[:idx], o.queue[idx+1:]...)
+	if err := o.save(); err != nil {
+		slog.Error("Error saving outbox", "error", err)
+	}
+}
+
+// config convierte el mensaje en un MessageConfig de Telegram
+func (m OutboundMessage) config() tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(m.ChatID, m.Text)
+	msg.ParseMode = m.ParseMode
+	if m.Keyboard != nil {
+		msg.ReplyMarkup = *m.Keyboard
+	}
+	return msg
+}
+
+// load carga la cola desde el archivo
+func (o *Outbox) load() error {
+	if o.file == "" {
+		return nil
+	}
+
+	data, err := storage.ReadFile(o.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &o.queue); err != nil {
+		return err
+	}
+
+	for _, msg := range o.queue {
+		if msg.ID >= o.nextID {
+			o.nextID = msg.ID + 1
+		}
+	}
+
+	return nil
+}
+
+// save guarda la cola en el archivo
+func (o *Outbox) save() error {
+	if o.file == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(o.queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(o.file, data, 0600)
+}
@@ -0,0 +1,219 @@
+package bot
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeSender registra los mensajes enviados y devuelve los errores configurados
+type fakeSender struct {
+	mu     sync.Mutex
+	sent   []tgbotapi.MessageConfig
+	times  []time.Time
+	errors []error
+}
+
+func (f *fakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.errors) > 0 {
+		err := f.errors[0]
+		f.errors = f.errors[1:]
+		if err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+
+	f.sent = append(f.sent, c.(tgbotapi.MessageConfig))
+	f.times = append(f.times, time.Now())
+	return tgbotapi.Message{}, nil
+}
+
+func (f *fakeSender) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+func newTestOutbox(api sender, file string) *Outbox {
+	o := NewOutbox(api, file)
+	o.GlobalInterval = time.Millisecond
+	o.PerChatInterval = 50 * time.Millisecond
+	o.BaseBackoff = 10 * time.Millisecond
+	return o
+}
+
+// TestOutboxDeliversInOrder prueba que los mensajes de un chat se entregan en orden
+func TestOutboxDeliversInOrder(t *testing.T) {
+	api := &fakeSender{}
+	o := newTestOutbox(api, "")
+
+	for _, text := range []string{"uno", "dos", "tres"} {
+		if err := o.Enqueue(OutboundMessage{ChatID: 1, Text: text}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	o.Start()
+	defer o.Stop()
+
+	if !o.WaitEmpty(2 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", o.Pending())
+	}
+
+	want := []string{"uno", "dos", "tres"}
+	for i, msg := range api.sent {
+		if msg.Text != want[i] {
+			t.Errorf("Message %d: expected %q, got %q", i, want[i], msg.Text)
+		}
+	}
+
+	// Verificar el límite por chat
+	for i := 1; i < len(api.times); i++ {
+		if gap := api.times[i].Sub(api.times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("Messages %d and %d sent only %s apart", i-1, i, gap)
+		}
+	}
+}
+
+// TestOutboxRetriesOn429 prueba que se respeta retry_after y se reintenta
+func TestOutboxRetriesOn429(t *testing.T) {
+	api := &fakeSender{errors: []error{
+		&tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}},
+	}}
+	o := newTestOutbox(api, "")
+
+	start := time.Now()
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "hola"})
+	o.Start()
+	defer o.Stop()
+
+	if !o.WaitEmpty(3 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", o.Pending())
+	}
+
+	if api.count() != 1 {
+		t.Fatalf("Expected 1 delivered message, got %d", api.count())
+	}
+	if elapsed := api.times[0].Sub(start); elapsed < time.Second {
+		t.Errorf("Expected retry after at least 1s, got %s", elapsed)
+	}
+}
+
+// TestOutboxFloodWaitPausesQueue prueba que un retry_after frena a todos los
+// chats, no solo al mensaje que lo recibió
+func TestOutboxFloodWaitPausesQueue(t *testing.T) {
+	api := &fakeSender{errors: []error{
+		&tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}},
+	}}
+	o := newTestOutbox(api, "")
+
+	start := time.Now()
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "uno"})
+	o.Enqueue(OutboundMessage{ChatID: 2, Text: "dos"})
+	o.Start()
+	defer o.Stop()
+
+	if !o.WaitEmpty(3 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", o.Pending())
+	}
+	for i, sent := range api.times {
+		if elapsed := sent.Sub(start); elapsed < time.Second {
+			t.Errorf("Message %d sent %s after start, during the flood wait", i, elapsed)
+		}
+	}
+}
+
+// TestOutboxRetriesTransientErrors prueba el backoff ante errores 5xx y de red
+func TestOutboxRetriesTransientErrors(t *testing.T) {
+	api := &fakeSender{errors: []error{
+		&tgbotapi.Error{Code: 502, Message: "Bad Gateway"},
+		errors.New("connection reset"),
+	}}
+	o := newTestOutbox(api, "")
+
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "hola"})
+	o.Start()
+	defer o.Stop()
+
+	if !o.WaitEmpty(2 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", o.Pending())
+	}
+	if api.count() != 1 {
+		t.Errorf("Expected 1 delivered message, got %d", api.count())
+	}
+}
+
+// TestOutboxDropsPermanentErrors prueba que los errores 4xx no se reintentan
+func TestOutboxDropsPermanentErrors(t *testing.T) {
+	api := &fakeSender{errors: []error{
+		&tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities"},
+	}}
+	o := newTestOutbox(api, "")
+
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "roto"})
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "ok"})
+	o.Start()
+	defer o.Stop()
+
+	if !o.WaitEmpty(2 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", o.Pending())
+	}
+	if api.count() != 1 || api.sent[0].Text != "ok" {
+		t.Errorf("Expected only the second message to be delivered, got %+v", api.sent)
+	}
+}
+
+// TestOutboxPersistsPending prueba que los mensajes no entregados sobreviven un reinicio
+func TestOutboxPersistsPending(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "outbox.json")
+
+	o := newTestOutbox(&fakeSender{}, file)
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "pendiente"})
+
+	// Simular reinicio: nueva cola sobre el mismo archivo
+	api := &fakeSender{}
+	restored := newTestOutbox(api, file)
+	if restored.Pending() != 1 {
+		t.Fatalf("Expected 1 pending message after restart, got %d", restored.Pending())
+	}
+
+	restored.Start()
+	defer restored.Stop()
+
+	if !restored.WaitEmpty(2 * time.Second) {
+		t.Fatalf("Outbox not drained, %d pending", restored.Pending())
+	}
+	if api.count() != 1 || api.sent[0].Text != "pendiente" {
+		t.Errorf("Expected restored message to be delivered, got %+v", api.sent)
+	}
+}
+
+// TestOutboxDeferredDoesNotBlock prueba que un mensaje diferido no frena a los
+// demás mensajes de su chat, y que se entrega al llegar su hora
+func TestOutboxDeferredDoesNotBlock(t *testing.T) {
+	o := newTestOutbox(&fakeSender{}, "")
+	now := time.Now()
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "después", NotBefore: now.Add(time.Hour), Deferred: true})
+	o.Enqueue(OutboundMessage{ChatID: 1, Text: "ahora"})
+
+	msg, _, ok := o.next(now)
+	if !ok || msg.Text != "ahora" {
+		t.Fatalf("Expected the non deferred message first, got %+v (ok=%v)", msg, ok)
+	}
+	o.remove(1)
+
+	_, wait, ok := o.next(now.Add(time.Second))
+	if ok || wait <= 59*time.Minute {
+		t.Errorf("Expected to wait for the deferred message, got ok=%v wait=%s", ok, wait)
+	}
+	if msg, _, ok := o.next(now.Add(time.Hour)); !ok || msg.Text != "después" {
+		t.Errorf("Expected the deferred message at its time, got %+v (ok=%v)", msg, ok)
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handlePause maneja el comando /pause [id] [AAAA-MM-DD]: pausa un hábito, o
+// todos, hasta la fecha indicada (inclusive) o hasta usar /resume
+func (b *Bot) handlePause(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	args := strings.Fields(message.CommandArguments())
+	id := 0
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			id, args = n, args[1:]
+		}
+	}
+	if len(args) > 1 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("pause.usage")))
+		return
+	}
+
+	from := b.today()
+	until := ""
+	if len(args) == 1 {
+		d, err := time.Parse(habits.DateFormat, args[0])
+		if err != nil {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("pause.invalid_date", args[0])))
+			return
+		}
+		if until = d.Format(habits.DateFormat); until < from {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("pause.past_date")))
+			return
+		}
+	}
+
+	targets := b.habitManager.GetActiveHabits()
+	if id != 0 {
+		habit, err := b.habitManager.GetHabit(id)
+		if err != nil {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+			return
+		}
+		targets = []habits.Habit{*habit}
+	}
+	if len(targets) == 0 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("listhabits.empty")))
+		return
+	}
+
+	for _, habit := range targets {
+		if _, err := b.habitManager.PauseHabit(habit.ID, from, until); err != nil {
+			logger.Error("Error pausing habit", "habit_id", habit.ID, "error", err)
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+			return
+		}
+		// Los recordatorios pospuestos y las insistencias ya no corresponden
+		if b.reminders != nil {
+			if err := b.reminders.cancel(habit.ID); err != nil {
+				logger.Error("Error saving reminders", "error", err)
+			}
+		}
+	}
+	logger.Info("Habits paused", "habit_id", id, "habits", len(targets), "until", until)
+
+	var text string
+	switch {
+	case id == 0 && until == "":
+		text = tr.T("pause.all")
+	case id == 0:
+		text = tr.T("pause.all_until", until)
+	case until == "":
+		text = tr.T("pause.habit", targets[0].Name, id)
+	default:
+		text = tr.T("pause.habit_until", targets[0].Name, until)
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// handleResume maneja el comando /resume [id]: reanuda un hábito pausado, o todos
+func (b *Bot) handleResume(ctx context.Context, message *tgbotapi.Message) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+	today := b.today()
+
+	if arg := strings.TrimSpace(message.CommandArguments()); arg != "" {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("invalid_id")))
+			return
+		}
+		habit, err := b.habitManager.ResumeHabit(id, today)
+		if errors.Is(err, habits.ErrNotPaused) {
+			if habit, err := b.habitManager.GetHabit(id); err == nil {
+				b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("resume.not_paused", habit.Name)))
+				return
+			}
+		}
+		if err != nil {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+			return
+		}
+		logger.Info("Habit resumed", "habit_id", id)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("resume.habit", habit.Name)))
+		return
+	}
+
+	resumed := 0
+	for _, habit := range b.habitManager.GetActiveHabits() {
+		if !habit.PausedOn(today) {
+			continue
+		}
+		if _, err := b.habitManager.ResumeHabit(habit.ID, today); err != nil {
+			logger.Error("Error resuming habit", "habit_id", habit.ID, "error", err)
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+			return
+		}
+		resumed++
+	}
+	if resumed == 0 {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("resume.none")))
+		return
+	}
+	logger.Info("Habits resumed", "habits", resumed)
+	b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("resume.all", resumed)))
+}
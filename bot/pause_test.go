@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPauseAndResume prueba /pause y /resume: los hábitos pausados no reciben
+// mensajes programados hasta que se reanudan
+func TestPauseAndResume(t *testing.T) {
+	b := newTestAccessBot(t)
+	b.SetUserChatID(testAllowedID)
+	ctx := context.Background()
+	today := time.Now().Format("2006-01-02")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	read, _ := b.habitManager.AddHabit("Read", "")
+	run, _ := b.habitManager.AddHabit("Run", "")
+
+	for _, arg := range []string{"2020-01-01", "mañana", "1 2 3"} {
+		b.processUpdate(ctx, privateMessage(testAllowedID, "/pause "+arg))
+		if sent := takeSent(b); len(sent) != 1 || strings.Contains(sent[0].Text, "Listo") {
+			t.Errorf("Expected /pause %s to be rejected, got %+v", arg, sent)
+		}
+	}
+
+	// Pausar un hábito lo saca del saludo y de /today
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/pause "+strconv.Itoa(run.ID)+" "+tomorrow))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, tomorrow) {
+		t.Fatalf("Expected the pause to be confirmed, got %+v", sent)
+	}
+	b.SendMorningGreeting()
+	sent := takeSent(b)
+	if len(sent) != 2 || !strings.Contains(sent[1].Text, "Read") {
+		t.Errorf("Expected only the unpaused habit in the greeting, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/today"))
+	if sent := takeSent(b); len(sent) != 1 || strings.Contains(sent[0].Text, "Run") {
+		t.Errorf("Expected the paused habit to be hidden from /today, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/listhabits"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "Run ⏸️") {
+		t.Errorf("Expected the paused habit to be marked, got %+v", sent)
+	}
+
+	// Con todos los hábitos pausados no se envía nada
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/pause"))
+	takeSent(b)
+	b.SendMorningGreeting()
+	b.SendEveningReview()
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Errorf("Expected no scheduled messages while paused, got %+v", sent)
+	}
+	if habit, _ := b.habitManager.GetHabit(run.ID); habit.PauseOn(today).To != "" {
+		t.Errorf("Expected the pause to be extended until /resume, got %+v", habit.Pauses)
+	}
+
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/resume "+strconv.Itoa(read.ID)))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "reanudé 'Read'") {
+		t.Errorf("Expected Read to be resumed, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/resume "+strconv.Itoa(read.ID)))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "no está pausado") {
+		t.Errorf("Expected Read not to be paused, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/resume"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "reanudé 1 hábitos") {
+		t.Errorf("Expected the remaining habit to be resumed, got %+v", sent)
+	}
+	for _, habit := range b.habitManager.GetActiveHabits() {
+		if habit.PausedOn(today) {
+			t.Errorf("Expected %s to be resumed, got %+v", habit.Name, habit.Pauses)
+		}
+	}
+}
+
+// TestScheduledSkipsHabitsNotDue prueba que el saludo y la revisión solo
+// preguntan por los hábitos que corresponden ese día de la semana
+func TestScheduledSkipsHabitsNotDue(t *testing.T) {
+	b := newTestAccessBot(t)
+	b.SetUserChatID(testAllowedID)
+	tomorrow := time.Now().AddDate(0, 0, 1).Weekday()
+
+	b.habitManager.AddHabit("Read", "")
+	run, _ := b.habitManager.AddHabit("Run", "")
+	if _, err := b.habitManager.SetSchedule(run.ID, []time.Weekday{tomorrow}, ""); err != nil {
+		t.Fatalf("SetSchedule failed: %v", err)
+	}
+
+	b.SendMorningGreeting()
+	sent := takeSent(b)
+	if len(sent) != 2 || strings.Contains(sent[1].Text, "Run") {
+		t.Errorf("Expected only the habit due today in the greeting, got %+v", sent)
+	}
+	b.SendEveningReview()
+	sent = takeSent(b)
+	if len(sent) != 2 || strings.Contains(sent[1].Text, "Run") {
+		t.Errorf("Expected only the habit due today in the review, got %+v", sent)
+	}
+}
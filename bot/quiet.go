@@ -0,0 +1,205 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/settings"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Cada usuario puede elegir un horario de silencio (/quiet) y pedir que no se
+// lo moleste por el resto del día (/dnd). Los mensajes programados (saludo,
+// revisión, recordatorios) pasan por sendScheduled, que dentro de esas
+// ventanas los difiere hasta el fin de la ventana o los descarta, según
+// QUIET_HOURS_MODE. Las respuestas a comandos y botones se envían siempre.
+
+// Modos del horario de silencio
+const (
+	QuietDefer = "defer" // Diferir los mensajes hasta el fin de la ventana
+	QuietDrop  = "drop"  // Descartar los mensajes
+)
+
+// SetQuietHours configura qué hacer con los mensajes programados que caen en
+// el horario de silencio de un usuario. Los horarios están en la zona
+// configurada con SetLocation.
+func (b *Bot) SetQuietHours(mode string) {
+	b.quietMode = mode
+}
+
+// parseQuietHours valida un horario de silencio HH:MM-HH:MM. Devuelve el
+// inicio y el fin en minutos desde la medianoche y el horario normalizado; el
+// fin puede ser anterior al inicio si la ventana pasa la medianoche.
+func parseQuietHours(s string) (int, int, string, error) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	if !ok {
+		return 0, 0, "", fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	start, err := time.Parse(habits.ReminderTimeFormat, from)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	end, err := time.Parse(habits.ReminderTimeFormat, to)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	if start.Equal(end) {
+		return 0, 0, "", fmt.Errorf("invalid quiet hours %q (empty window)", s)
+	}
+	normalized := start.Format(habits.ReminderTimeFormat) + "-" + end.Format(habits.ReminderTimeFormat)
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), normalized, nil
+}
+
+// quietUntil indica si now cae en el horario de silencio o el "no molestar"
+// de un usuario y, en ese caso, cuándo termina la ventana
+func (b *Bot) quietUntil(userID int64, now time.Time) (time.Time, bool) {
+	if b.settings == nil {
+		return time.Time{}, false
+	}
+	u := b.settings.Get(userID)
+	loc := b.location()
+	now = now.In(loc)
+	y, m, d := now.Date()
+
+	if u.DoNotDisturb == now.Format(habits.DateFormat) {
+		return time.Date(y, m, d+1, 0, 0, 0, 0, loc), true
+	}
+	if u.QuietHours == "" {
+		return time.Time{}, false
+	}
+	start, end, _, err := parseQuietHours(u.QuietHours)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	switch {
+	case start < end && minute >= start && minute < end:
+		return time.Date(y, m, d, 0, end, 0, 0, loc), true
+	case start > end && minute >= start:
+		return time.Date(y, m, d+1, 0, end, 0, 0, loc), true
+	case start > end && minute < end:
+		return time.Date(y, m, d, 0, end, 0, 0, loc), true
+	}
+	return time.Time{}, false
+}
+
+// sendScheduled encola un mensaje programado respetando el horario de silencio
+// y el "no molestar" del destinatario. Los mensajes no se difieren al día
+// siguiente porque sus botones se refieren al día en que se programaron: en
+// ese caso se descartan también en modo defer, y /quiet y /dnd lo avisan al
+// configurarlos. Las revisiones que no llegan se retoman con closeReview.
+func (b *Bot) sendScheduled(msg tgbotapi.MessageConfig) error {
+	now := b.now()
+	until, quiet := b.quietUntil(msg.ChatID, now)
+	if !quiet {
+		return b.send(msg)
+	}
+
+	if b.quietMode == QuietDrop {
+		slog.Info("Scheduled message dropped during quiet hours", "chat_id", msg.ChatID)
+		return nil
+	}
+	if until.Format(habits.DateFormat) != now.Format(habits.DateFormat) {
+		slog.Info("Scheduled message dropped: quiet hours end on a later day", "chat_id", msg.ChatID, "until", until)
+		return nil
+	}
+
+	out := outboundMessage(msg)
+	out.NotBefore = until
+	out.Deferred = true
+	slog.Info("Scheduled message deferred until the end of quiet hours", "chat_id", msg.ChatID, "until", until)
+	return b.enqueue(out)
+}
+
+// handleQuiet maneja el comando /quiet [HH:MM-HH:MM|off]: el horario en que
+// no se envían mensajes programados
+func (b *Bot) handleQuiet(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if b.settings == nil || message.From == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.disabled")))
+		return
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	var value string
+	switch arg {
+	case "":
+		if current := b.settings.Get(message.From.ID).QuietHours; current != "" {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.current", current)))
+		} else {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.none")))
+		}
+		return
+	case "off":
+	default:
+		_, _, normalized, err := parseQuietHours(arg)
+		if err != nil {
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.invalid", arg)))
+			return
+		}
+		value = normalized
+	}
+
+	if err := b.settings.Update(message.From.ID, func(u *settings.User) { u.QuietHours = value }); err != nil {
+		logging.FromContext(ctx).Error("Error saving quiet hours", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.error")))
+		return
+	}
+	logging.FromContext(ctx).Info("Quiet hours changed", "quiet_hours", value)
+
+	if value == "" {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.cleared")))
+		return
+	}
+	start, end, _ := strings.Cut(value, "-")
+	text := tr.T("quiet.set", start, end)
+	// En modo defer, lo que cae antes de la medianoche no se difiere (ver sendScheduled)
+	if b.quietMode != QuietDrop && start > end {
+		text += "\n" + tr.T("quiet.next_day", end)
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
+
+// handleDoNotDisturb maneja el comando /dnd [off]: no enviar mensajes
+// programados por el resto del día
+func (b *Bot) handleDoNotDisturb(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if b.settings == nil || message.From == nil {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.disabled")))
+		return
+	}
+
+	var value string
+	switch strings.ToLower(strings.TrimSpace(message.CommandArguments())) {
+	case "":
+		value = b.today()
+	case "off":
+	default:
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("dnd.usage")))
+		return
+	}
+
+	if err := b.settings.Update(message.From.ID, func(u *settings.User) { u.DoNotDisturb = value }); err != nil {
+		logging.FromContext(ctx).Error("Error saving do not disturb", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("quiet.error")))
+		return
+	}
+	logging.FromContext(ctx).Info("Do not disturb changed", "date", value)
+
+	if value == "" {
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("dnd.off")))
+		return
+	}
+	text := tr.T("dnd.on")
+	if b.quietMode != QuietDrop {
+		text += "\n" + tr.T("dnd.next_day")
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"habittracker/settings"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestParseQuietHours prueba la validación y normalización del horario de silencio
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end int
+		normalized string
+		wantErr    bool
+	}{
+		{in: "22:00-07:00", start: 22 * 60, end: 7 * 60, normalized: "22:00-07:00"},
+		{in: "13:30 - 15:00", start: 13*60 + 30, end: 15 * 60, normalized: "13:30-15:00"},
+		{in: "9:00-9:30", start: 9 * 60, end: 9*60 + 30, normalized: "09:00-09:30"},
+		{in: "22:00", wantErr: true},
+		{in: "22:00-25:00", wantErr: true},
+		{in: "08:00-08:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		start, end, normalized, err := parseQuietHours(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseQuietHours(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (start != tt.start || end != tt.end || normalized != tt.normalized) {
+			t.Errorf("parseQuietHours(%q) = %d, %d, %q", tt.in, start, end, normalized)
+		}
+	}
+}
+
+// newTestQuietBot crea un bot de prueba con preferencias de usuario
+func newTestQuietBot(t *testing.T) *Bot {
+	b := newTestAccessBot(t)
+	store, err := settings.NewStore(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatalf("Failed to create settings: %v", err)
+	}
+	b.SetSettings(store)
+	b.SetQuietHours(QuietDefer)
+	b.SetUserChatID(testAllowedID)
+	return b
+}
+
+// TestQuietUntil prueba las ventanas de silencio, incluidas las que pasan la medianoche
+func TestQuietUntil(t *testing.T) {
+	b := newTestQuietBot(t)
+	b.settings.Update(testAllowedID, func(u *settings.User) { u.QuietHours = "22:00-07:00" })
+	day := func(d, hour, min int) time.Time { return time.Date(2024, 3, d, hour, min, 0, 0, time.Local) }
+
+	tests := []struct {
+		now   time.Time
+		until time.Time
+		quiet bool
+	}{
+		{now: day(6, 21, 59)},
+		{now: day(6, 22, 0), until: day(7, 7, 0), quiet: true},
+		{now: day(7, 6, 59), until: day(7, 7, 0), quiet: true},
+		{now: day(7, 7, 0)},
+	}
+	for _, tt := range tests {
+		until, quiet := b.quietUntil(testAllowedID, tt.now)
+		if quiet != tt.quiet || !until.Equal(tt.until) {
+			t.Errorf("quietUntil(%s) = %s, %v; want %s, %v", tt.now, until, quiet, tt.until, tt.quiet)
+		}
+	}
+	if _, quiet := b.quietUntil(testAdminID, day(6, 23, 0)); quiet {
+		t.Error("Expected quiet hours to be per user")
+	}
+
+	b.settings.Update(testAllowedID, func(u *settings.User) { u.DoNotDisturb = "2024-03-06" })
+	if until, quiet := b.quietUntil(testAllowedID, day(6, 12, 0)); !quiet || !until.Equal(day(7, 0, 0)) {
+		t.Errorf("Expected do not disturb until midnight, got %s, %v", until, quiet)
+	}
+	if _, quiet := b.quietUntil(testAllowedID, day(7, 12, 0)); quiet {
+		t.Error("Expected do not disturb to last only for the day")
+	}
+}
+
+// TestQuietCommands prueba /quiet y /dnd, y que los mensajes programados
+// respetan la ventana de silencio
+func TestQuietCommands(t *testing.T) {
+	b := newTestQuietBot(t)
+	ctx := context.Background()
+	scheduled := tgbotapi.NewMessage(testAllowedID, "recordatorio")
+
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/quiet 25:00"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "inválido") {
+		t.Errorf("Expected an invalid hours message, got %+v", sent)
+	}
+
+	now := time.Now()
+	start, end := now.Add(-time.Minute), now.Add(time.Hour)
+	if end.Day() != now.Day() {
+		t.Skip("Too close to midnight to defer a message to the same day")
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/quiet "+start.Format("15:04")+"-"+end.Format("15:04")))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, end.Format("15:04")) || strings.Contains(sent[0].Text, "medianoche") {
+		t.Errorf("Expected the quiet hours to be confirmed, got %+v", sent)
+	}
+
+	// Dentro de la ventana el mensaje se difiere hasta su fin
+	b.sendScheduled(scheduled)
+	sent := takeSent(b)
+	if len(sent) != 1 || !sent[0].Deferred || sent[0].NotBefore.Before(end.Add(-time.Minute)) {
+		t.Fatalf("Expected the message to be deferred until %s, got %+v", end.Format("15:04"), sent)
+	}
+
+	// Una ventana que pasa la medianoche avisa que lo anterior no se difiere
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/quiet 22:00-07:00"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "medianoche") {
+		t.Errorf("Expected a notice about messages before midnight, got %+v", sent)
+	}
+
+	// Las respuestas a comandos no se difieren
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/quiet off"))
+	if sent := takeSent(b); len(sent) != 1 || sent[0].Deferred {
+		t.Errorf("Expected an immediate reply, got %+v", sent)
+	}
+	b.sendScheduled(scheduled)
+	if sent := takeSent(b); len(sent) != 1 || sent[0].Deferred {
+		t.Errorf("Expected the message to be sent without quiet hours, got %+v", sent)
+	}
+
+	// "No molestar" descarta los mensajes del resto del día
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/dnd"))
+	if sent := takeSent(b); len(sent) != 1 || !strings.Contains(sent[0].Text, "no te lo envío más tarde") {
+		t.Errorf("Expected a notice that today's messages are not deferred, got %+v", sent)
+	}
+	b.sendScheduled(scheduled)
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Errorf("Expected the message to be dropped, got %+v", sent)
+	}
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/dnd off"))
+	takeSent(b)
+	if dnd := b.settings.Get(testAllowedID).DoNotDisturb; dnd != "" {
+		t.Errorf("Expected do not disturb to be cleared, got %q", dnd)
+	}
+
+	// En modo drop, la ventana de silencio también descarta
+	b.SetQuietHours(QuietDrop)
+	b.settings.Update(testAllowedID, func(u *settings.User) {
+		u.QuietHours = start.Format("15:04") + "-" + end.Format("15:04")
+	})
+	b.sendScheduled(scheduled)
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Errorf("Expected the message to be dropped, got %+v", sent)
+	}
+}
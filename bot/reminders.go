@@ -0,0 +1,302 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/storage"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Los recordatorios por hábito se envían a las horas configuradas con
+// /schedule. CheckReminders se ejecuta cada minuto y envía los recordatorios
+// cuya hora pasó desde la revisión anterior, así los cambios de horario se
+// aplican sin reprogramar nada. Si el hábito sigue sin marcar, se envía una
+// insistencia a los pocos minutos; los botones de posponer vuelven a enviar el
+// recordatorio más tarde. Las insistencias y los pospuestos pendientes se
+// guardan en un archivo para sobrevivir a los reinicios.
+
+// snoozeOptions son los botones para posponer un recordatorio, por acción
+var snoozeOptions = map[string]time.Duration{
+	"s15": 15 * time.Minute,
+	"s60": time.Hour,
+}
+
+// FollowUp es un recordatorio pendiente: una insistencia o un recordatorio pospuesto
+type FollowUp struct {
+	HabitID int       `json:"habit_id"`
+	Date    string    `json:"date"` // Día del recordatorio original
+	Due     time.Time `json:"due"`
+	Nudge   bool      `json:"nudge,omitempty"` // true: insistencia; false: pospuesto
+}
+
+// Reminders guarda los recordatorios pendientes en un archivo JSON
+type Reminders struct {
+	pending   []FollowUp
+	nudge     time.Duration
+	lastCheck time.Time
+	file      string
+	mu        sync.Mutex
+}
+
+// NewReminders crea el almacén y carga los recordatorios pendientes. nudge es
+// el tiempo tras el cual se insiste si el hábito no se marcó (0: no se insiste).
+func NewReminders(file string, nudge time.Duration) (*Reminders, error) {
+	r := &Reminders{file: file, nudge: nudge}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// add agrega un recordatorio pendiente
+func (r *Reminders) add(f FollowUp) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, f)
+	return r.save()
+}
+
+// cancel descarta los recordatorios pendientes de un hábito
+func (r *Reminders) cancel(habitID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.pending[:0]
+	for _, f := range r.pending {
+		if f.HabitID != habitID {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == len(r.pending) {
+		return nil
+	}
+	r.pending = kept
+	return r.save()
+}
+
+// window devuelve el intervalo desde la revisión anterior hasta now y los
+// recordatorios pendientes que vencen en él, que se descartan
+func (r *Reminders) window(now time.Time) (time.Time, []FollowUp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := r.lastCheck
+	if since.IsZero() || since.After(now) {
+		since = now.Add(-time.Minute)
+	}
+	r.lastCheck = now
+
+	var due, kept []FollowUp
+	for _, f := range r.pending {
+		if f.Due.After(now) {
+			kept = append(kept, f)
+		} else {
+			due = append(due, f)
+		}
+	}
+	if len(due) == 0 {
+		return since, nil, nil
+	}
+	r.pending = kept
+	return since, due, r.save()
+}
+
+// load carga los recordatorios pendientes desde el archivo
+func (r *Reminders) load() error {
+	data, err := storage.ReadFile(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &r.pending)
+}
+
+// save guarda los recordatorios pendientes en el archivo
+func (r *Reminders) save() error {
+	data, err := json.MarshalIndent(r.pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(r.file, data, 0600)
+}
+
+// SetReminders habilita los recordatorios por hábito
+func (b *Bot) SetReminders(r *Reminders) {
+	b.reminders = r
+}
+
+// CheckReminders envía los recordatorios de los hábitos cuya hora pasó desde
+// la revisión anterior, y las insistencias y los pospuestos que vencieron
+func (b *Bot) CheckReminders(now time.Time) {
+	if b.reminders == nil {
+		return
+	}
+	if b.GetUserChatID() == 0 {
+		slog.Warn("No user chat ID available yet, skipping habit reminders")
+		return
+	}
+
+	since, due, err := b.reminders.window(now)
+	if err != nil {
+		slog.Error("Error saving reminders", "error", err)
+	}
+
+	date := now.Format(habits.DateFormat)
+	for _, habit := range b.habitManager.GetActiveHabits() {
+		if !habit.DueOn(date) {
+			continue
+		}
+		for _, hhmm := range habit.ReminderTimes() {
+			t, _ := time.ParseInLocation(habits.ReminderTimeFormat, hhmm, now.Location())
+			at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+			if !at.After(since) || at.After(now) {
+				continue
+			}
+			if b.sendReminder(habit, date, "reminder.text") && b.reminders.nudge > 0 {
+				follow := FollowUp{HabitID: habit.ID, Date: date, Due: at.Add(b.reminders.nudge), Nudge: true}
+				if err := b.reminders.add(follow); err != nil {
+					slog.Error("Error saving reminder nudge", "habit_id", habit.ID, "error", err)
+				}
+			}
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+	for _, f := range due {
+		habit, err := b.habitManager.GetHabit(f.HabitID)
+		if err != nil || habit.Archived || habit.PausedOn(date) || f.Date != date {
+			continue
+		}
+		key := "reminder.text"
+		if f.Nudge {
+			key = "reminder.nudge"
+		}
+		b.sendReminder(*habit, date, key)
+	}
+}
+
+// sendReminder envía un recordatorio con los botones para marcar, saltar o
+// posponer. No envía nada si el hábito ya se marcó o se saltó hoy.
+func (b *Bot) sendReminder(habit habits.Habit, date, key string) bool {
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		if log.HabitID == habit.ID && (log.Completed || !log.Planned) {
+			return false
+		}
+	}
+
+	tr := b.userLocalizer(b.GetUserChatID())
+	row := func(actions ...string) ([]tgbotapi.InlineKeyboardButton, error) {
+		var buttons []tgbotapi.InlineKeyboardButton
+		for _, action := range actions {
+			data, err := callbackData{Type: "remind", Action: action, HabitID: habit.ID, Owner: b.GetUserChatID()}.encode(b.callbackKey)
+			if err != nil {
+				return nil, err
+			}
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(tr.T("reminder.button."+action), data))
+		}
+		return buttons, nil
+	}
+	mark, err := row("done", "skip")
+	if err != nil {
+		slog.Error("Error building reminder keyboard", "habit_id", habit.ID, "error", err)
+		return false
+	}
+	snooze, err := row("s15", "s60")
+	if err != nil {
+		slog.Error("Error building reminder keyboard", "habit_id", habit.ID, "error", err)
+		return false
+	}
+
+	msg := htmlMessage(b.GetUserChatID(), tr, key, habit.Name)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(mark, snooze)
+	if err := b.sendScheduled(msg); err != nil {
+		slog.Error("Error sending habit reminder", "habit_id", habit.ID, "error", err)
+		return false
+	}
+	slog.Info("Habit reminder sent", "habit_id", habit.ID, "nudge", key == "reminder.nudge")
+	return true
+}
+
+// handleReminderCallback aplica un botón de un recordatorio: marcar, saltar o posponer
+func (b *Bot) handleReminderCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, data callbackData) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	habit, err := b.habitManager.GetHabit(data.HabitID)
+	if err != nil || b.reminders == nil {
+		logger.Warn("Reminder button for a missing habit", "habit_id", data.HabitID)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+		return
+	}
+
+	// Cualquier respuesta reemplaza a la insistencia pendiente
+	if err := b.reminders.cancel(habit.ID); err != nil {
+		logger.Error("Error saving reminders", "error", err)
+	}
+
+	now := b.now()
+	date := now.Format(habits.DateFormat)
+	var text string
+	switch data.Action {
+	case "done", "skip":
+		if data.Action == "done" {
+			err = b.habitManager.RecordCompletion(date, habit.ID, true)
+		} else {
+			err = b.habitManager.RecordPlan(date, habit.ID, false)
+		}
+		if err != nil {
+			logger.Error("Error recording reminder answer", "action", data.Action, "habit_id", habit.ID, "error", err)
+			b.request(tgbotapi.NewCallback(callback.ID, tr.T("error", err)))
+			return
+		}
+		tmplData := b.templateData(tr, callback.From.ID, now)
+		tmplData.Habit = b.templateHabits([]habits.Habit{*habit}, tmplData.Date)[0]
+		tmplData.Action, tmplData.Done = "review", true
+		if data.Action == "skip" {
+			tmplData.Action, tmplData.Done = "plan", false
+		}
+		text = b.render(tr, "confirmation", tmplData)
+	default:
+		delay, ok := snoozeOptions[data.Action]
+		if !ok {
+			err = fmt.Errorf("unknown action %q", data.Action)
+			logger.Error("Error recording reminder answer", "habit_id", habit.ID, "error", err)
+			b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+			return
+		}
+		due := now.Add(delay)
+		follow := FollowUp{HabitID: habit.ID, Date: date, Due: due}
+		if err := b.reminders.add(follow); err != nil {
+			logger.Error("Error saving snoozed reminder", "habit_id", habit.ID, "error", err)
+			b.request(tgbotapi.NewCallback(callback.ID, tr.T("error", err)))
+			return
+		}
+		text = tr.T("reminder.snoozed", due.Format(habits.ReminderTimeFormat), escapeHTML(habit.Name))
+	}
+	logger.Info("Reminder answered", "action", data.Action, "habit_id", habit.ID)
+
+	b.request(tgbotapi.NewCallback(callback.ID, plainText(text)))
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	b.request(edit)
+
+	if data.Action == "done" {
+		b.finishReview(tr, callback.Message.Chat.ID, habit.ID, now)
+	}
+}
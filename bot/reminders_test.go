@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHabitReminders prueba los recordatorios por hábito: la hora de cada
+// uno, la insistencia, posponer y que no se recuerde lo ya marcado
+func TestHabitReminders(t *testing.T) {
+	b := newTestAccessBot(t)
+	fake := newFakeAPI(t, b)
+	b.SetUserChatID(testAllowedID)
+	reminders, err := NewReminders(filepath.Join(t.TempDir(), "reminders.json"), 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create reminders: %v", err)
+	}
+	b.SetReminders(reminders)
+	ctx := context.Background()
+
+	meditate, _ := b.habitManager.AddHabit("Meditate", "")
+	b.habitManager.AddHabit("Vitamins", "")
+	if _, err := b.habitManager.SetSchedule(meditate.ID, nil, "13:00, 7:00"); err != nil {
+		t.Fatalf("Failed to set schedule: %v", err)
+	}
+
+	now := time.Now()
+	at := func(hour, min int) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day(), hour, min, 30, 0, time.Local)
+	}
+	check := func(when time.Time) []OutboundMessage {
+		b.CheckReminders(when)
+		return takeSent(b)
+	}
+
+	if sent := check(at(6, 59)); len(sent) != 0 {
+		t.Errorf("Expected no reminders before 07:00, got %d", len(sent))
+	}
+	sent := check(at(7, 0))
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "<b>Meditate</b>") {
+		t.Fatalf("Expected the 07:00 reminder, got %+v", sent)
+	}
+	if kb := sent[0].Keyboard; kb == nil || len(kb.InlineKeyboard) != 2 || len(kb.InlineKeyboard[1]) != 2 {
+		t.Fatalf("Expected mark and snooze buttons, got %+v", sent[0].Keyboard)
+	}
+	if sent := check(at(7, 1)); len(sent) != 0 {
+		t.Errorf("Expected each reminder to be sent once, got %d", len(sent))
+	}
+
+	// Sin respuesta, el bot insiste una vez
+	sent = check(at(7, 30))
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "Todavía no lo marcaste") {
+		t.Fatalf("Expected a nudge, got %+v", sent)
+	}
+	snooze := *sent[0].Keyboard.InlineKeyboard[1][0].CallbackData
+
+	// Posponer reemplaza la insistencia por un recordatorio 15 minutos después
+	before := time.Now()
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, snooze))
+	if len(reminders.pending) != 1 || reminders.pending[0].Nudge {
+		t.Fatalf("Expected a single snoozed reminder, got %+v", reminders.pending)
+	}
+	if due := reminders.pending[0].Due; due.Before(before.Add(15*time.Minute)) || due.After(time.Now().Add(15*time.Minute)) {
+		t.Errorf("Expected the reminder 15 minutes later, got %s", due)
+	}
+	if edits := fake.take("editMessageText"); len(edits) != 1 || !strings.Contains(edits[0]["text"], "Meditate") {
+		t.Errorf("Expected the reminder to be edited, got %+v", edits)
+	}
+
+	reminders.pending[0].Due = at(7, 45)
+	sent = check(at(7, 45))
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "Es la hora") {
+		t.Fatalf("Expected the snoozed reminder, got %+v", sent)
+	}
+
+	// Marcado como hecho, no se recuerda más en el día
+	done := *sent[0].Keyboard.InlineKeyboard[0][0].CallbackData
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, done))
+	if sent := check(at(13, 0)); len(sent) != 0 {
+		t.Errorf("Expected no reminder for a completed habit, got %+v", sent)
+	}
+	completed := false
+	for _, log := range b.habitManager.GetDailyPlans(now.Format("2006-01-02")) {
+		completed = completed || (log.HabitID == meditate.ID && log.Completed)
+	}
+	if !completed {
+		t.Error("Expected the reminder button to record the completion")
+	}
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"fmt"
+	"habittracker/i18n"
+	"html"
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Los mensajes con formato se envían en el modo HTML de Telegram: a diferencia de
+// Markdown, solo <, > y & tienen significado, así que un nombre de hábito como
+// "read_books" o "a*b" no rompe el mensaje. Todo contenido del usuario pasa por
+// escapeHTML; los mensajes sin ParseMode se muestran tal cual y no necesitan escaparse.
+
+// htmlMessage arma un mensaje HTML a partir de un mensaje del catálogo. Los
+// argumentos de texto se escapan; el formato del catálogo se respeta.
+func htmlMessage(chatID int64, tr *i18n.Localizer, key string, args ...any) tgbotapi.MessageConfig {
+	return htmlText(chatID, tr.T(key, escapeArgs(args)...))
+}
+
+// htmlText arma un mensaje HTML con un texto ya escapado
+func htmlText(chatID int64, text string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg
+}
+
+// escapeHTML escapa texto del usuario para incluirlo en un mensaje HTML
+func escapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+var htmlTags = regexp.MustCompile(`<[^>]+>`)
+
+// plainText convierte un texto HTML en texto plano, para los avisos de los
+// callbacks que no admiten formato
+func plainText(s string) string {
+	return html.UnescapeString(htmlTags.ReplaceAllString(s, ""))
+}
+
+// escapeArgs escapa los argumentos de formato que pueden contener texto
+func escapeArgs(args []any) []any {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			escaped[i] = escapeHTML(v)
+		case error:
+			escaped[i] = escapeHTML(v.Error())
+		case fmt.Stringer:
+			escaped[i] = escapeHTML(v.String())
+		default:
+			escaped[i] = arg
+		}
+	}
+	return escaped
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"habittracker/auth"
+	"habittracker/i18n"
+	"habittracker/settings"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// hostileNames son nombres de hábitos que rompían los mensajes en Markdown
+var hostileNames = []string{
+	"read_books",
+	"a*b",
+	"`code`",
+	"[link](http://example.com)",
+	"<b>bold</b> & <script>",
+	"_*[]()~`>#+-=|{}.!\\",
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`<(/?)([a-z]+)(?: [^<>]*)?>`)
+	htmlEntity = regexp.MustCompile(`^&(lt|gt|amp|quot|#\d+);`)
+	allowedTag = map[string]bool{"b": true, "i": true, "u": true, "s": true, "code": true, "pre": true, "a": true}
+)
+
+// checkTelegramHTML verifica que el texto sea HTML aceptado por Telegram: solo
+// etiquetas soportadas y balanceadas, y <, > y & escapados en el texto
+func checkTelegramHTML(text string) error {
+	var open []string
+	pos := 0
+	for _, m := range htmlTag.FindAllStringSubmatchIndex(text, -1) {
+		if err := checkHTMLText(text[pos:m[0]]); err != nil {
+			return err
+		}
+		closing, name := text[m[2]:m[3]] == "/", text[m[4]:m[5]]
+		if !allowedTag[name] {
+			return fmt.Errorf("unsupported tag <%s>", name)
+		}
+		if !closing {
+			open = append(open, name)
+		} else if len(open) == 0 || open[len(open)-1] != name {
+			return fmt.Errorf("unbalanced </%s>", name)
+		} else {
+			open = open[:len(open)-1]
+		}
+		pos = m[1]
+	}
+	if err := checkHTMLText(text[pos:]); err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed <%s>", open[len(open)-1])
+	}
+	return nil
+}
+
+func checkHTMLText(s string) error {
+	if strings.ContainsAny(s, "<>") {
+		return fmt.Errorf("unescaped < or > in %q", s)
+	}
+	for i := strings.IndexByte(s, '&'); i != -1; i = strings.IndexByte(s, '&') {
+		if !htmlEntity.MatchString(s[i:]) {
+			return fmt.Errorf("unescaped & in %q", s)
+		}
+		s = s[i+1:]
+	}
+	return nil
+}
+
+// TestFormattedMessagesEscapeUserContent envía todos los mensajes con formato,
+// en todos los idiomas, con nombres de hábitos hostiles
+func TestFormattedMessagesEscapeUserContent(t *testing.T) {
+	for _, lang := range i18n.Languages() {
+		t.Run(lang, func(t *testing.T) {
+			b := newTestAccessBot(t)
+			dir := t.TempDir()
+			store, _ := settings.NewStore(filepath.Join(dir, "settings.json"))
+			store.Update(testAllowedID, func(u *settings.User) { u.Language = lang })
+			b.SetSettings(store)
+			tokens, _ := auth.NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+			b.SetTokenStore(tokens)
+			b.SetUserChatID(testAllowedID)
+
+			for _, name := range hostileNames {
+				if _, err := b.habitManager.AddHabit(name, ""); err != nil {
+					t.Fatalf("Failed to add habit %q: %v", name, err)
+				}
+			}
+
+			ctx := context.Background()
+			for _, command := range []string{"/start", "/help", "/listhabits", "/apitoken", "/schedule 1 diario 07:30", "/users"} {
+				b.processUpdate(ctx, privateMessage(testAllowedID, command))
+			}
+			b.SendMorningGreeting()
+			b.SendEveningReview()
+			b.send(htmlMessage(testAllowedID, i18n.Get(lang), "import.done", 1, 2, 3, 4))
+
+			var formatted []string
+			for _, msg := range takeSent(b) {
+				switch msg.ParseMode {
+				case "":
+					continue
+				case tgbotapi.ModeHTML:
+					formatted = append(formatted, msg.Text)
+				default:
+					t.Errorf("Unexpected parse mode %q for %q", msg.ParseMode, msg.Text)
+				}
+				if err := checkTelegramHTML(msg.Text); err != nil {
+					t.Errorf("Invalid HTML (%v):\n%s", err, msg.Text)
+				}
+			}
+
+			// Los nombres llegan intactos: en la lista, en la planificación y en la revisión
+			all := html.UnescapeString(strings.Join(formatted, "\n"))
+			for _, name := range hostileNames {
+				if got := strings.Count(all, name); got < 3 {
+					t.Errorf("Expected %q in 3 formatted messages, found %d", name, got)
+				}
+			}
+		})
+	}
+}
+
+// TestEscapeArgs prueba que solo se escapan los argumentos de texto
+func TestEscapeArgs(t *testing.T) {
+	got := escapeArgs([]any{"<a>", 3, fmt.Errorf("x & y")})
+	if got[0] != "&lt;a&gt;" || got[1] != 3 || got[2] != "x &amp; y" {
+		t.Errorf("Unexpected escaped args: %#v", got)
+	}
+}
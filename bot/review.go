@@ -0,0 +1,364 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/storage"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// La revisión nocturna pregunta por cada hábito si se hizo. Los hábitos que
+// siguen sin respuesta se vuelven a preguntar antes de que termine el día
+// (SendReviewReminder) y, a la mañana siguiente, se registran como
+// desconocidos en lugar de quedar sin datos, con un mensaje para resolverlos
+// de un toque.
+
+// buttonDateFormat es el formato de la fecha en los botones de planificación,
+// revisión y para resolver un día
+const buttonDateFormat = "20060102"
+
+// reviewState es la revisión nocturna en curso
+type reviewState struct {
+	Date    string `json:"date"`
+	Habits  []int  `json:"habits"`  // Hábitos enviados
+	Pending []int  `json:"pending"` // Hábitos que todavía no se respondieron
+}
+
+// Reviews guarda la revisión nocturna en curso en un archivo JSON, para saber
+// qué quedó sin responder aun después de reiniciar. Sin archivo, el estado
+// solo se guarda en memoria.
+type Reviews struct {
+	state reviewState
+	file  string
+	mu    sync.Mutex
+}
+
+// NewReviews crea el almacén y carga la revisión en curso
+func NewReviews(file string) (*Reviews, error) {
+	r := &Reviews{file: file}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// start registra los hábitos enviados en la revisión de date. Reemplaza a la
+// revisión anterior, que ya debería estar cerrada.
+func (r *Reviews) start(date string, ids []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = reviewState{Date: date, Habits: ids, Pending: append([]int{}, ids...)}
+	return r.save()
+}
+
+// answer marca un hábito de la revisión de date como respondido. Si era el
+// último, devuelve todos los hábitos de la revisión.
+func (r *Reviews) answer(date string, habitID int) ([]int, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state.Date != date {
+		return nil, false, nil
+	}
+	kept := r.state.Pending[:0]
+	for _, id := range r.state.Pending {
+		if id != habitID {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == len(r.state.Pending) {
+		return nil, false, nil
+	}
+	r.state.Pending = kept
+	if len(kept) > 0 {
+		return nil, false, r.save()
+	}
+	return r.state.Habits, true, r.save()
+}
+
+// pending devuelve los hábitos sin responder de la revisión de date
+func (r *Reviews) pending(date string) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state.Date != date {
+		return nil
+	}
+	return append([]int{}, r.state.Pending...)
+}
+
+// close cierra la revisión si es de un día anterior a today y devuelve su
+// fecha y los hábitos que quedaron sin responder
+func (r *Reviews) close(today string) (string, []int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state.Date == "" || r.state.Date >= today {
+		return "", nil, nil
+	}
+	closed := r.state
+	r.state = reviewState{}
+	return closed.Date, closed.Pending, r.save()
+}
+
+// load carga la revisión en curso desde el archivo
+func (r *Reviews) load() error {
+	if r.file == "" {
+		return nil
+	}
+	data, err := storage.ReadFile(r.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &r.state)
+}
+
+// save guarda la revisión en curso en el archivo
+func (r *Reviews) save() error {
+	if r.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(r.file, data, 0600)
+}
+
+// SetReviews guarda la revisión nocturna en curso en un archivo
+func (b *Bot) SetReviews(r *Reviews) {
+	b.reviews = r
+}
+
+// startReview registra los hábitos enviados en la revisión nocturna
+func (b *Bot) startReview(date string, list []habits.Habit) {
+	ids := make([]int, 0, len(list))
+	for _, h := range list {
+		ids = append(ids, h.ID)
+	}
+	if err := b.reviews.start(date, ids); err != nil {
+		slog.Error("Error saving review state", "error", err)
+	}
+}
+
+// finishReview marca un hábito de la revisión como respondido y, si era el
+// último, envía el resumen del día
+func (b *Bot) finishReview(tr *i18n.Localizer, chatID int64, habitID int, now time.Time) {
+	date := now.Format(habits.DateFormat)
+
+	ids, done, err := b.reviews.answer(date, habitID)
+	if err != nil {
+		slog.Error("Error saving review state", "error", err)
+	}
+	if !done {
+		return
+	}
+
+	var reviewed []habits.Habit
+	for _, id := range ids {
+		if habit, err := b.habitManager.GetHabit(id); err == nil {
+			reviewed = append(reviewed, *habit)
+		}
+	}
+
+	data := b.templateData(tr, chatID, now)
+	data.Habits = b.templateHabits(reviewed, date)
+	data.Total = len(data.Habits)
+	for _, h := range data.Habits {
+		if h.Completed {
+			data.Completed++
+		}
+	}
+	b.send(htmlText(chatID, b.render(tr, "summary", data)))
+}
+
+// SendReviewReminder vuelve a preguntar por los hábitos de la revisión
+// nocturna que siguen sin respuesta, antes de que termine el día
+func (b *Bot) SendReviewReminder() error {
+	if b.GetUserChatID() == 0 {
+		slog.Warn("No user chat ID available yet, skipping review reminder")
+		return nil
+	}
+
+	date := b.today()
+	logs := make(map[int]habits.DailyLog)
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		logs[log.HabitID] = log
+	}
+
+	// Los hábitos marcados o saltados por otro camino (/today, recordatorios) ya tienen respuesta
+	var pending []habits.Habit
+	for _, id := range b.reviews.pending(date) {
+		log, logged := logs[id]
+		if log.Completed || (logged && !log.Planned) {
+			continue
+		}
+		if habit, err := b.habitManager.GetHabit(id); err == nil && !habit.Archived && !habit.PausedOn(date) {
+			pending = append(pending, *habit)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tr := b.userLocalizer(b.GetUserChatID())
+	if err := b.sendScheduled(htmlMessage(b.GetUserChatID(), tr, "review.reminder", len(pending))); err != nil {
+		return err
+	}
+	for _, habit := range pending {
+		b.sendReviewHabit(tr, habit, b.now())
+	}
+	slog.Info("Review reminder sent", "pending", len(pending))
+	return nil
+}
+
+// closeReview cierra la revisión de un día anterior: los hábitos que quedaron
+// sin responder se registran como desconocidos y se ofrece resolverlos
+func (b *Bot) closeReview(tr *i18n.Localizer, now time.Time) {
+	date, pending, err := b.reviews.close(now.Format(habits.DateFormat))
+	if err != nil {
+		slog.Error("Error saving review state", "error", err)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := b.habitManager.MarkUnknown(date, pending); err != nil {
+		slog.Error("Error recording unanswered review", "date", date, "error", err)
+		return
+	}
+	slog.Info("Unanswered review recorded as unknown", "date", date, "habits", len(pending))
+
+	text, keyboard, err := b.resolveMessage(tr, b.GetUserChatID(), date)
+	if err != nil {
+		slog.Error("Error building resolve keyboard", "error", err)
+		return
+	}
+	if keyboard == nil {
+		return
+	}
+	msg := htmlText(b.GetUserChatID(), text)
+	msg.ReplyMarkup = *keyboard
+	if err := b.sendScheduled(msg); err != nil {
+		slog.Error("Error sending unanswered review", "date", date, "error", err)
+	}
+}
+
+// resolveMessage arma el mensaje para responder los hábitos desconocidos de
+// date. El teclado es nil si no queda ninguno.
+func (b *Bot) resolveMessage(tr *i18n.Localizer, owner int64, date string) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	day, _ := time.Parse(habits.DateFormat, date)
+	button := func(label string, completed bool, habitID int) (tgbotapi.InlineKeyboardButton, error) {
+		action := "n" + day.Format(buttonDateFormat)
+		if completed {
+			action = "y" + day.Format(buttonDateFormat)
+		}
+		data, err := callbackData{Type: "resolve", Action: action, HabitID: habitID, Owner: owner}.encode(b.callbackKey)
+		return tgbotapi.NewInlineKeyboardButtonData(label, data), err
+	}
+
+	var text strings.Builder
+	text.WriteString(tr.T("resolve.title", date) + "\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, log := range b.habitManager.GetUnknown(date) {
+		habit, err := b.habitManager.GetHabit(log.HabitID)
+		if err != nil {
+			continue
+		}
+		text.WriteString(tr.T("resolve.habit", escapeHTML(habit.Name)) + "\n")
+
+		yes, err := button(tr.T("resolve.button.yes", habit.Name), true, habit.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		no, err := button(tr.T("resolve.button.no"), false, habit.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(yes, no))
+	}
+
+	if len(rows) == 0 {
+		return tr.T("resolve.done", date), nil, nil
+	}
+
+	// Responder todos de una vez (HabitID 0)
+	all, err := button(tr.T("resolve.button.all_yes"), true, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	none, err := button(tr.T("resolve.button.all_no"), false, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(all, none))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return strings.TrimSpace(text.String()), &keyboard, nil
+}
+
+// handleResolveCallback registra la respuesta tardía de un hábito desconocido
+// (o de todos, si HabitID es 0) y actualiza el mensaje
+func (b *Bot) handleResolveCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, data callbackData) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	var day time.Time
+	err := fmt.Errorf("unknown action %q", data.Action)
+	if len(data.Action) > 1 && (data.Action[0] == 'y' || data.Action[0] == 'n') {
+		day, err = time.Parse(buttonDateFormat, data.Action[1:])
+	}
+	if err != nil {
+		logger.Warn("Invalid resolve button", "action", data.Action, "error", err)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+		return
+	}
+	date := day.Format(habits.DateFormat)
+	completed := data.Action[0] == 'y'
+
+	ids := []int{data.HabitID}
+	if data.HabitID == 0 {
+		ids = nil
+		for _, log := range b.habitManager.GetUnknown(date) {
+			ids = append(ids, log.HabitID)
+		}
+	}
+	for _, id := range ids {
+		// Un hábito ya resuelto (por ejemplo, un doble toque) se ignora
+		if err := b.habitManager.ResolveUnknown(date, id, completed); err != nil && !errors.Is(err, habits.ErrNotFound) {
+			logger.Error("Error resolving unknown day", "date", date, "habit_id", id, "error", err)
+			b.request(tgbotapi.NewCallback(callback.ID, tr.T("error", err)))
+			return
+		}
+	}
+	logger.Info("Unknown day resolved", "date", date, "habits", len(ids), "completed", completed)
+
+	b.request(tgbotapi.NewCallback(callback.ID, tr.T("resolve.answered")))
+
+	text, keyboard, err := b.resolveMessage(tr, callback.From.ID, date)
+	if err != nil {
+		logger.Error("Error building resolve keyboard", "error", err)
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = keyboard
+	b.request(edit)
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"habittracker/i18n"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUnansweredReview prueba que la revisión nocturna sin responder se vuelve
+// a preguntar, se registra como desconocida al día siguiente y se puede resolver
+func TestUnansweredReview(t *testing.T) {
+	b := newTestAccessBot(t)
+	fake := newFakeAPI(t, b)
+	b.SetUserChatID(testAllowedID)
+	file := filepath.Join(t.TempDir(), "reviews.json")
+	reviews, err := NewReviews(file)
+	if err != nil {
+		t.Fatalf("Failed to create review state: %v", err)
+	}
+	b.SetReviews(reviews)
+	ctx := context.Background()
+
+	read, _ := b.habitManager.AddHabit("Read", "")
+	run, _ := b.habitManager.AddHabit("Run", "")
+	b.habitManager.AddHabit("Swim", "")
+
+	if err := b.SendEveningReview(); err != nil {
+		t.Fatalf("SendEveningReview failed: %v", err)
+	}
+	sent := takeSent(b)
+	if len(sent) != 4 {
+		t.Fatalf("Expected the review and 3 habits, got %d", len(sent))
+	}
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, *sent[1].Keyboard.InlineKeyboard[0][0].CallbackData))
+	fake.take("editMessageText")
+
+	// La revisión en curso sobrevive a un reinicio
+	date := time.Now().Format("2006-01-02")
+	reloaded, err := NewReviews(file)
+	if err != nil {
+		t.Fatalf("Failed to reload review state: %v", err)
+	}
+	if pending := reloaded.pending(date); len(pending) != 2 {
+		t.Errorf("Expected 2 pending habits after reloading, got %v", pending)
+	}
+
+	if err := b.SendReviewReminder(); err != nil {
+		t.Fatalf("SendReviewReminder failed: %v", err)
+	}
+	sent = takeSent(b)
+	if len(sent) != 3 || !strings.Contains(sent[0].Text, "Quedan 2 hábitos") || strings.Contains(sent[1].Text+sent[2].Text, "Read") {
+		t.Fatalf("Expected a reminder for the 2 unanswered habits, got %+v", sent)
+	}
+
+	// A la mañana siguiente lo pendiente queda como desconocido
+	b.closeReview(i18n.Get("es"), time.Now().AddDate(0, 0, 1))
+	if unknown := b.habitManager.GetUnknown(date); len(unknown) != 2 {
+		t.Fatalf("Expected 2 unknown habits, got %+v", unknown)
+	}
+	sent = takeSent(b)
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "❔ Run") || !strings.Contains(sent[0].Text, "❔ Swim") {
+		t.Fatalf("Expected the resolve message, got %+v", sent)
+	}
+	if err := checkTelegramHTML(sent[0].Text); err != nil {
+		t.Errorf("Invalid HTML (%v):\n%s", err, sent[0].Text)
+	}
+	keyboard := sent[0].Keyboard
+	if keyboard == nil || len(keyboard.InlineKeyboard) != 3 {
+		t.Fatalf("Expected a row per habit and one for all, got %+v", keyboard)
+	}
+
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, *keyboard.InlineKeyboard[0][0].CallbackData))
+	edits := fake.take("editMessageText")
+	if len(edits) != 1 || strings.Contains(edits[0]["text"], "Run") || !strings.Contains(edits[0]["text"], "Swim") {
+		t.Fatalf("Expected the resolved habit to be removed, got %+v", edits)
+	}
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, *keyboard.InlineKeyboard[2][1].CallbackData))
+	edits = fake.take("editMessageText")
+	if len(edits) != 1 || !strings.Contains(edits[0]["text"], "quedó registrado") || edits[0]["reply_markup"] != "" {
+		t.Errorf("Expected the day to be resolved, got %+v", edits)
+	}
+
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		want := log.HabitID == read.ID || log.HabitID == run.ID
+		if log.Unknown || log.Completed != want {
+			t.Errorf("Unexpected log after resolving: %+v", log)
+		}
+	}
+	if unknown := b.habitManager.GetUnknown(date); len(unknown) != 0 {
+		t.Errorf("Expected no unknown habits, got %+v", unknown)
+	}
+
+	// Una segunda mañana no vuelve a preguntar
+	b.closeReview(i18n.Get("es"), time.Now().AddDate(0, 0, 2))
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Errorf("Expected the review to be closed once, got %+v", sent)
+	}
+}
+
+// TestLateReviewAnswer prueba que un botón de la revisión presionado después
+// de medianoche se registra en el día de la pregunta
+func TestLateReviewAnswer(t *testing.T) {
+	b := newTestAccessBot(t)
+	fake := newFakeAPI(t, b)
+	b.SetUserChatID(testAllowedID)
+	habit, _ := b.habitManager.AddHabit("Read", "")
+
+	yesterday := b.now().AddDate(0, 0, -1)
+	keyboard, err := b.habitKeyboard("review", habit.ID, yesterday, "Sí", "No")
+	if err != nil {
+		t.Fatalf("Failed to build keyboard: %v", err)
+	}
+	b.processUpdate(context.Background(), callbackUpdate(testAllowedID, *keyboard.InlineKeyboard[0][0].CallbackData))
+	fake.take("editMessageText")
+
+	if logs := b.habitManager.GetDailyPlans(yesterday.Format("2006-01-02")); len(logs) != 1 || !logs[0].Completed {
+		t.Errorf("Expected the answer to be recorded yesterday, got %+v", logs)
+	}
+	if logs := b.habitManager.GetDailyPlans(b.today()); len(logs) != 0 {
+		t.Errorf("Expected nothing recorded today, got %+v", logs)
+	}
+}
@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*/*.tmpl
+var templateFS embed.FS
+
+// templateNames son las plantillas de mensajes que se pueden personalizar
+var templateNames = []string{"greeting", "review", "confirmation", "summary"}
+
+// TemplateUser es el usuario que recibe el mensaje
+type TemplateUser struct {
+	ID       int64
+	Name     string // Nombre de Telegram (vacío si todavía no se conoce)
+	Language string
+}
+
+// TemplateHabit es un hábito con su registro del día y sus rachas
+type TemplateHabit struct {
+	ID            int
+	Name          string
+	ReminderTime  string // HH:MM, vacío si no tiene
+	Planned       bool
+	Completed     bool
+	CurrentStreak int
+	BestStreak    int
+}
+
+// TemplateData son los datos de las plantillas. Los textos ya vienen escapados
+// para el HTML de Telegram.
+type TemplateData struct {
+	User    TemplateUser
+	Date    string // YYYY-MM-DD
+	Weekday string // Día de la semana abreviado, en el idioma del usuario
+	Habits  []TemplateHabit
+
+	// Solo en confirmation: el botón presionado
+	Habit  TemplateHabit
+	Action string // plan o review
+	Done   bool   // true si respondió que sí
+
+	// Solo en summary
+	Completed int
+	Total     int
+}
+
+// Templates son las plantillas de mensajes por idioma. Las de un directorio
+// reemplazan a las embebidas archivo por archivo: <dir>/<idioma>/<nombre>.tmpl.
+type Templates struct {
+	sets     map[string]*template.Template
+	defaults map[string]*template.Template
+}
+
+// defaultTemplates son las plantillas embebidas, usadas si no se configuró otro directorio
+var defaultTemplates = mustLoadTemplates()
+
+func mustLoadTemplates() *Templates {
+	t, err := LoadTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplates carga las plantillas embebidas y las de dir (vacío: solo las
+// embebidas), y las valida ejecutándolas con datos de ejemplo
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{
+		sets:     make(map[string]*template.Template),
+		defaults: make(map[string]*template.Template),
+	}
+
+	for _, lang := range i18n.Languages() {
+		set := template.New(lang).Option("missingkey=error")
+		def := template.New(lang).Option("missingkey=error")
+		for _, name := range templateNames {
+			data, err := fs.ReadFile(templateFS, "templates/"+lang+"/"+name+".tmpl")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := def.New(name).Parse(string(data)); err != nil {
+				return nil, fmt.Errorf("embedded template %s/%s: %w", lang, name, err)
+			}
+
+			source := "embedded"
+			if dir != "" {
+				path := filepath.Join(dir, lang, name+".tmpl")
+				custom, err := os.ReadFile(path)
+				if err == nil {
+					data, source = custom, path
+				} else if !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+			}
+			if _, err := set.New(name).Parse(string(data)); err != nil {
+				return nil, fmt.Errorf("template %s: %w", source, err)
+			}
+			if err := validateTemplate(set, name); err != nil {
+				return nil, fmt.Errorf("template %s: %w", source, err)
+			}
+		}
+		t.sets[lang] = set
+		t.defaults[lang] = def
+	}
+	return t, nil
+}
+
+// validateTemplate ejecuta una plantilla con datos de ejemplo, con y sin hábitos,
+// para detectar campos inexistentes antes de enviar mensajes
+func validateTemplate(set *template.Template, name string) error {
+	habit := TemplateHabit{ID: 1, Name: "Leer", ReminderTime: "07:30", Planned: true, Completed: true, CurrentStreak: 3, BestStreak: 5}
+	samples := []TemplateData{
+		{User: TemplateUser{ID: 1, Name: "Ana"}, Date: "2024-03-06", Weekday: "mie", Habits: []TemplateHabit{habit}, Habit: habit, Action: "review", Done: true, Completed: 1, Total: 1},
+		{Date: "2024-03-06", Action: "plan"},
+	}
+	for _, data := range samples {
+		if err := set.ExecuteTemplate(&bytes.Buffer{}, name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render ejecuta una plantilla en el idioma dado. Si la plantilla personalizada
+// falla se usa la embebida.
+func (t *Templates) Render(lang, name string, data TemplateData) (string, error) {
+	set, ok := t.sets[lang]
+	if !ok {
+		lang = i18n.Get(lang).Lang()
+		set = t.sets[lang]
+	}
+
+	var buf bytes.Buffer
+	err := set.ExecuteTemplate(&buf, name, data)
+	if err != nil {
+		buf.Reset()
+		if defErr := t.defaults[lang].ExecuteTemplate(&buf, name, data); defErr != nil {
+			return "", defErr
+		}
+	}
+	return strings.TrimSpace(buf.String()), err
+}
+
+// SetTemplates configura las plantillas de los mensajes
+func (b *Bot) SetTemplates(t *Templates) {
+	b.templates = t
+}
+
+// render ejecuta una plantilla de mensaje. Los errores de las plantillas
+// personalizadas se registran y el mensaje sale con la plantilla embebida.
+func (b *Bot) render(tr *i18n.Localizer, name string, data TemplateData) string {
+	t := b.templates
+	if t == nil {
+		t = defaultTemplates
+	}
+	data.User.Language = tr.Lang()
+	text, err := t.Render(tr.Lang(), name, data)
+	if err != nil {
+		slog.Error("Error rendering message template", "template", tr.Lang()+"/"+name, "error", err)
+	}
+	return text
+}
+
+// templateData arma los datos comunes de las plantillas: el usuario y la fecha
+func (b *Bot) templateData(tr *i18n.Localizer, userID int64, now time.Time) TemplateData {
+	user := TemplateUser{ID: userID}
+	if b.settings != nil {
+		user.Name = escapeHTML(b.settings.Get(userID).Name)
+	}
+	return TemplateData{
+		User:    user,
+		Date:    now.Format(habits.DateFormat),
+		Weekday: tr.T(fmt.Sprintf("weekday.%d", now.Weekday())),
+	}
+}
+
+// templateHabits arma los hábitos de las plantillas con su registro del día y sus rachas
+func (b *Bot) templateHabits(list []habits.Habit, date string) []TemplateHabit {
+	logs := make(map[int]habits.DailyLog)
+	for _, log := range b.habitManager.GetDailyLogs(date, date, 0) {
+		logs[log.HabitID] = log
+	}
+
+	result := make([]TemplateHabit, 0, len(list))
+	for _, h := range list {
+		th := TemplateHabit{
+			ID:           h.ID,
+			Name:         escapeHTML(h.Name),
+			ReminderTime: h.ReminderTime,
+			Planned:      logs[h.ID].Planned,
+			Completed:    logs[h.ID].Completed,
+		}
+		if stats, err := b.habitManager.GetStats(h.ID, date, date); err == nil {
+			th.CurrentStreak, th.BestStreak = stats.CurrentStreak, stats.BestStreak
+		}
+		result = append(result, th)
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"habittracker/i18n"
+	"habittracker/settings"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadTemplates prueba que las plantillas de un directorio reemplazan a las
+// embebidas y que las inválidas se rechazan al cargarlas
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "en"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	custom := "Hi {{.User.Name}}, {{len .Habits}} habits for {{.Weekday}}"
+	if err := os.WriteFile(filepath.Join(dir, "en", "greeting.tmpl"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("Failed to load templates: %v", err)
+	}
+	data := TemplateData{User: TemplateUser{Name: "Ana"}, Weekday: "Wed", Habits: []TemplateHabit{{Name: "Leer"}}}
+	if got, _ := templates.Render("en", "greeting", data); got != "Hi Ana, 1 habits for Wed" {
+		t.Errorf("Unexpected custom greeting: %q", got)
+	}
+	if got, _ := templates.Render("es", "greeting", data); !strings.Contains(got, "Buenos días") {
+		t.Errorf("Expected embedded greeting for es, got %q", got)
+	}
+
+	for name, source := range map[string]string{
+		"unknown field": "{{.Nope}}",
+		"syntax error":  "{{if .Done}}",
+		"bad habit":     "{{range .Habits}}{{.Streak}}{{end}}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "en", "summary.tmpl"), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), "summary.tmpl") {
+			t.Errorf("%s: expected error naming the template, got %v", name, err)
+		}
+	}
+}
+
+// TestReviewSummary prueba que el resumen se envía al responder el último
+// hábito de la revisión nocturna, y una sola vez
+func TestReviewSummary(t *testing.T) {
+	b := newTestAccessBot(t)
+	store, _ := settings.NewStore(filepath.Join(t.TempDir(), "settings.json"))
+	store.Update(testAllowedID, func(u *settings.User) { u.Language = "en" })
+	b.SetSettings(store)
+	b.SetUserChatID(testAllowedID)
+
+	read, _ := b.habitManager.AddHabit("Read <books>", "")
+	run, _ := b.habitManager.AddHabit("Run", "")
+	b.habitManager.RecordPlan(b.today(), read.ID, true)
+	b.habitManager.RecordPlan(b.today(), run.ID, true)
+
+	if err := b.SendEveningReview(); err != nil {
+		t.Fatalf("Failed to send review: %v", err)
+	}
+	takeSent(b)
+
+	tr, now := i18n.Get("en"), time.Now()
+	b.habitManager.RecordCompletion(b.today(), read.ID, true)
+	b.finishReview(tr, testAllowedID, read.ID, now)
+	b.finishReview(tr, testAllowedID, read.ID, now)
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Fatalf("Expected no summary before the last answer, got %d messages", len(sent))
+	}
+
+	b.habitManager.RecordCompletion(b.today(), run.ID, false)
+	b.finishReview(tr, testAllowedID, run.ID, now)
+	sent := takeSent(b)
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 summary, got %d messages", len(sent))
+	}
+	summary := sent[0].Text
+	for _, want := range []string{"1 of 2", "✅ Read &lt;books&gt;", "❌ Run"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in summary:\n%s", want, summary)
+		}
+	}
+	if err := checkTelegramHTML(summary); err != nil {
+		t.Errorf("Invalid HTML (%v):\n%s", err, summary)
+	}
+
+	b.finishReview(tr, testAllowedID, run.ID, now)
+	if sent := takeSent(b); len(sent) != 0 {
+		t.Errorf("Expected a single summary, got %d more messages", len(sent))
+	}
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleToday maneja el comando /today: el estado de los hábitos del día con
+// botones para marcarlos como hechos, saltarlos o deshacer
+func (b *Bot) handleToday(ctx context.Context, message *tgbotapi.Message) {
+	tr := i18n.FromContext(ctx)
+	if message.From == nil {
+		return
+	}
+
+	text, keyboard, err := b.todayMessage(tr, message.From.ID, b.now())
+	if err != nil {
+		logging.FromContext(ctx).Error("Error building today keyboard", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+		return
+	}
+	msg := htmlText(message.Chat.ID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	b.send(msg)
+}
+
+// todayMessage arma el mensaje de /today. El teclado es nil si no hay hábitos para hoy.
+func (b *Bot) todayMessage(tr *i18n.Localizer, owner int64, now time.Time) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	date := now.Format(habits.DateFormat)
+	logs := make(map[int]habits.DailyLog)
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		logs[log.HabitID] = log
+	}
+
+	var text strings.Builder
+	text.WriteString(tr.T("today.title", tr.T(fmt.Sprintf("weekday.%d", now.Weekday())), date) + "\n\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, habit := range b.habitManager.GetActiveHabits() {
+		if !habit.DueOn(date) {
+			continue
+		}
+		log, logged := logs[habit.ID]
+		skipped := logged && !log.Planned && !log.Completed
+
+		// Estado del hábito y los botones que lo cambian
+		var state string
+		var actions []string
+		switch {
+		case log.Completed:
+			state, actions = "today.completed", []string{"undo"}
+		case skipped:
+			state, actions = "today.skipped", []string{"done", "undo"}
+		case log.Planned:
+			state, actions = "today.planned", []string{"done", "skip"}
+		default:
+			state, actions = "today.pending", []string{"done", "skip"}
+		}
+		text.WriteString(tr.T(state, escapeHTML(habit.Name)) + "\n")
+
+		var row []tgbotapi.InlineKeyboardButton
+		for i, action := range actions {
+			data, err := callbackData{Type: "today", Action: action, HabitID: habit.ID, Owner: owner}.encode(b.callbackKey)
+			if err != nil {
+				return "", nil, err
+			}
+			// El primer botón lleva el nombre del hábito; los demás, solo el ícono
+			label := tr.T("today.button."+action, habit.Name)
+			if i > 0 {
+				label = tr.T("today.button." + action + "_short")
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data))
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return tr.T("today.empty"), nil, nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return strings.TrimSpace(text.String()), &keyboard, nil
+}
+
+// handleTodayCallback aplica un botón de /today y actualiza el mensaje
+func (b *Bot) handleTodayCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, data callbackData) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	habit, err := b.habitManager.GetHabit(data.HabitID)
+	if err != nil {
+		logger.Warn("Today button for a missing habit", "habit_id", data.HabitID)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("callback.invalid")))
+		return
+	}
+
+	now := b.now()
+	date := now.Format(habits.DateFormat)
+	completed := false
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		if log.HabitID == habit.ID {
+			completed = log.Completed
+		}
+	}
+
+	switch data.Action {
+	case "done":
+		err = b.habitManager.RecordCompletion(date, habit.ID, true)
+	case "skip":
+		err = b.habitManager.RecordPlan(date, habit.ID, false)
+	case "undo":
+		// Deshacer vuelve un paso atrás: de completado a pendiente, de saltado a planeado
+		if completed {
+			err = b.habitManager.RecordCompletion(date, habit.ID, false)
+		} else {
+			err = b.habitManager.RecordPlan(date, habit.ID, true)
+		}
+	default:
+		err = fmt.Errorf("unknown action %q", data.Action)
+	}
+	if err != nil {
+		logger.Error("Error recording today action", "action", data.Action, "habit_id", habit.ID, "error", err)
+		b.request(tgbotapi.NewCallback(callback.ID, tr.T("error", err)))
+		return
+	}
+	logger.Info("Today action recorded", "action", data.Action, "habit_id", habit.ID)
+
+	b.request(tgbotapi.NewCallback(callback.ID, tr.T("today.marked_"+data.Action, habit.Name)))
+
+	text, keyboard, err := b.todayMessage(tr, callback.From.ID, now)
+	if err != nil {
+		logger.Error("Error building today keyboard", "error", err)
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = keyboard
+	b.request(edit)
+
+	// Marcar un hábito desde /today también responde la revisión nocturna
+	if data.Action == "done" {
+		b.finishReview(tr, callback.Message.Chat.ID, habit.ID, now)
+	}
+}
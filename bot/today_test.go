@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"context"
+	"habittracker/habits"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeTelegram es un servidor de la API de Telegram que acepta todos los
+// pedidos y registra los métodos y parámetros recibidos
+type fakeTelegram struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+}
+
+type fakeRequest struct {
+	method string
+	params map[string]string
+}
+
+// newFakeAPI conecta el bot a un servidor falso de Telegram para los pedidos
+// directos (callbacks, ediciones), que no pasan por la cola de salida
+func newFakeAPI(t *testing.T, b *Bot) *fakeTelegram {
+	fake := &fakeTelegram{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		params := make(map[string]string)
+		for k := range r.Form {
+			params[k] = r.Form.Get(k)
+		}
+		method := path.Base(r.URL.Path)
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, fakeRequest{method: method, params: params})
+		fake.mu.Unlock()
+
+		if method == "getMe" {
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"test_bot"}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	api, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create fake API: %v", err)
+	}
+	b.api = api
+	return fake
+}
+
+// take devuelve los pedidos recibidos de un método y los descarta
+func (f *fakeTelegram) take(method string) []map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []map[string]string
+	var rest []fakeRequest
+	for _, r := range f.requests {
+		if r.method == method {
+			found = append(found, r.params)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	f.requests = rest
+	return found
+}
+
+// callbackUpdate simula que un usuario presiona un botón de un mensaje del bot
+func callbackUpdate(userID int64, data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb",
+		From:    &tgbotapi.User{ID: userID},
+		Message: &tgbotapi.Message{MessageID: 10, Chat: &tgbotapi.Chat{ID: userID, Type: "private"}},
+		Data:    data,
+	}}
+}
+
+// TestToday prueba /today: solo los hábitos del día, y los botones para
+// marcarlos, saltarlos y deshacer
+func TestToday(t *testing.T) {
+	b := newTestAccessBot(t)
+	fake := newFakeAPI(t, b)
+	ctx := context.Background()
+
+	read, _ := b.habitManager.AddHabit("Read <books>", "")
+	run, _ := b.habitManager.AddHabit("Run", "")
+	other, _ := b.habitManager.AddHabit("Gym", "")
+	tomorrow := time.Now().AddDate(0, 0, 1).Weekday()
+	b.habitManager.SetSchedule(other.ID, []time.Weekday{tomorrow}, "")
+	b.habitManager.RecordPlan(b.today(), run.ID, true)
+
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/today"))
+	sent := takeSent(b)
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(sent))
+	}
+	msg := sent[0]
+	for _, want := range []string{"⬜ Read &lt;books&gt;", "🎯 Run"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected %q in:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Contains(msg.Text, "Gym") {
+		t.Errorf("Expected habit not due today to be hidden:\n%s", msg.Text)
+	}
+	if err := checkTelegramHTML(msg.Text); err != nil {
+		t.Errorf("Invalid HTML (%v):\n%s", err, msg.Text)
+	}
+
+	keyboard := msg.Keyboard
+	if keyboard == nil {
+		t.Fatal("Expected a keyboard")
+	}
+	if len(keyboard.InlineKeyboard) != 2 {
+		t.Fatalf("Expected a row per habit due today, got %d", len(keyboard.InlineKeyboard))
+	}
+	button := func(row, col int) string {
+		return *keyboard.InlineKeyboard[row][col].CallbackData
+	}
+	done, skip := button(0, 0), button(1, 1)
+
+	// Otro usuario no puede usar los botones
+	b.processUpdate(ctx, callbackUpdate(testAdminID, done))
+	if edits := fake.take("editMessageText"); len(edits) != 0 {
+		t.Errorf("Expected buttons of another user to be rejected")
+	}
+
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, done))
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, skip))
+	edits := fake.take("editMessageText")
+	if len(edits) != 2 {
+		t.Fatalf("Expected 2 edits, got %d", len(edits))
+	}
+	last := edits[1]["text"]
+	for _, want := range []string{"✅ Read &lt;books&gt;", "⏭️ <s>Run</s>"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("Expected %q in:\n%s", want, last)
+		}
+	}
+	if !strings.Contains(edits[1]["reply_markup"], "Deshacer") {
+		t.Errorf("Expected undo buttons, got %s", edits[1]["reply_markup"])
+	}
+
+	// Deshacer vuelve al estado anterior
+	date := time.Now().Format("2006-01-02")
+	b.processUpdate(ctx, privateMessage(testAllowedID, "/today"))
+	keyboard = takeSent(b)[0].Keyboard
+	undoRead, undoRun := button(0, 0), button(1, 1)
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, undoRead))
+	b.processUpdate(ctx, callbackUpdate(testAllowedID, undoRun))
+
+	for _, log := range b.habitManager.GetDailyPlans(date) {
+		switch log.HabitID {
+		case read.ID:
+			if log.Completed {
+				t.Errorf("Expected undo to clear the completion: %+v", log)
+			}
+		case run.ID:
+			if !log.Planned || log.Completed {
+				t.Errorf("Expected undo to restore the plan: %+v", log)
+			}
+		}
+	}
+	if answers := fake.take("answerCallbackQuery"); len(answers) != 5 {
+		t.Errorf("Expected every button to be answered, got %d answers", len(answers))
+	}
+}
+
+// TestTodayUsesConfiguredZone prueba que los botones registran el día de la
+// zona horaria configurada, no el de la zona del sistema
+func TestTodayUsesConfiguredZone(t *testing.T) {
+	b := newTestAccessBot(t)
+	newFakeAPI(t, b)
+	ctx := context.Background()
+	read, _ := b.habitManager.AddHabit("Read", "")
+	// Creado antes, para que corresponda también en el día de UTC-12
+	b.habitManager.Merge([]habits.Habit{{Name: "Read", CreatedAt: time.Now().AddDate(0, 0, -2)}}, nil, false)
+
+	// Las dos zonas están siempre en días distintos: al menos una no coincide con la del sistema
+	for _, loc := range []*time.Location{time.FixedZone("UTC+14", 14*3600), time.FixedZone("UTC-12", -12*3600)} {
+		b.SetLocation(loc)
+		b.processUpdate(ctx, privateMessage(testAllowedID, "/today"))
+		keyboard := takeSent(b)[0].Keyboard
+		b.processUpdate(ctx, callbackUpdate(testAllowedID, *keyboard.InlineKeyboard[0][0].CallbackData))
+
+		date := time.Now().In(loc).Format("2006-01-02")
+		logs := b.habitManager.GetDailyPlans(date)
+		if len(logs) != 1 || logs[0].HabitID != read.ID || !logs[0].Completed {
+			t.Errorf("Expected the habit to be marked on %s (%s), got %+v", date, loc, logs)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// secretTokenHeader es el header con el que Telegram envía el secret_token del webhook
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookOptions son los parámetros de setWebhook
+type WebhookOptions struct {
+	URL                string
+	SecretToken        string   // Telegram lo envía en cada update; el handler lo verifica
+	AllowedUpdates     []string // vacío: los tipos que Telegram envía por defecto
+	MaxConnections     int      // 0: el valor por defecto de Telegram (40)
+	DropPendingUpdates bool
+}
+
+// WebhookStatus es el estado del webhook devuelto por getWebhookInfo
+type WebhookStatus struct {
+	tgbotapi.WebhookInfo
+	LastErrorTime *time.Time `json:"last_error_time,omitempty"`
+}
+
+// ConfigureWebhook registra el webhook en Telegram.
+// Se arma la petición a mano porque tgbotapi no soporta secret_token.
+func ConfigureWebhook(api *tgbotapi.BotAPI, opts WebhookOptions) error {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute https URL", opts.URL)
+	}
+
+	params := make(tgbotapi.Params)
+	params["url"] = u.String()
+	params.AddNonEmpty("secret_token", opts.SecretToken)
+	params.AddNonZero("max_connections", opts.MaxConnections)
+	params.AddBool("drop_pending_updates", opts.DropPendingUpdates)
+	if len(opts.AllowedUpdates) > 0 {
+		if err := params.AddInterface("allowed_updates", opts.AllowedUpdates); err != nil {
+			return err
+		}
+	}
+
+	if _, err := api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
+
+// RemoveWebhook elimina el webhook (el bot vuelve a poder usar long polling)
+func RemoveWebhook(api *tgbotapi.BotAPI, dropPendingUpdates bool) error {
+	if _, err := api.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: dropPendingUpdates}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// FetchWebhookStatus consulta el estado del webhook
+func FetchWebhookStatus(api *tgbotapi.BotAPI) (WebhookStatus, error) {
+	info, err := api.GetWebhookInfo()
+	if err != nil {
+		return WebhookStatus{}, fmt.Errorf("failed to get webhook info: %w", err)
+	}
+
+	status := WebhookStatus{WebhookInfo: info}
+	if info.LastErrorDate > 0 {
+		t := time.Unix(int64(info.LastErrorDate), 0)
+		status.LastErrorTime = &t
+	}
+	return status, nil
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWebhookHandlerRejectsInvalidSecret prueba que el handler rechaza updates sin el secret_token correcto
+func TestWebhookHandlerRejectsInvalidSecret(t *testing.T) {
+	b := &Bot{webhookSecret: "s3cret"}
+	handler := b.GetWebhookHandler()
+
+	for _, header := range []string{"", "wrong"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id": 1}`))
+		if header != "" {
+			req.Header.Set(secretTokenHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for secret %q, got %d", header, rec.Code)
+		}
+	}
+
+	// Con el secret correcto el update llega a la validación del cuerpo
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json"))
+	req.Header.Set(secretTokenHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a valid secret and invalid body, got %d", rec.Code)
+	}
+}
+
+// TestPingCachesResult prueba que una respuesta correcta de Telegram se
+// reutiliza en los sondeos siguientes
+func TestPingCachesResult(t *testing.T) {
+	b := &Bot{}
+	fake := newFakeAPI(t, b)
+	fake.take("getMe")
+
+	for i := 0; i < 3; i++ {
+		if err := b.Ping(); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+	}
+	if calls := len(fake.take("getMe")); calls != 1 {
+		t.Errorf("Expected a single getMe call, got %d", calls)
+	}
+}
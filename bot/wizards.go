@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// flows son los asistentes disponibles, por nombre
+var flows = map[string]*flow{
+	"addhabit": {
+		steps: []flowStep{
+			{key: "name", prompt: "addhabit.ask_name", invalid: "addhabit.invalid_name", parse: parseNameAnswer},
+			{key: "description", prompt: "addhabit.ask_description", optional: true},
+			{key: "days", prompt: "addhabit.ask_days", invalid: "addhabit.invalid_days", optional: true, parse: parseDaysAnswer},
+			{key: "reminder", prompt: "addhabit.ask_reminder", invalid: "addhabit.invalid_reminder", optional: true, parse: parseReminderAnswer},
+		},
+		finish: (*Bot).finishAddHabit,
+	},
+}
+
+// parseNameAnswer valida el nombre de un hábito
+func parseNameAnswer(text string) (string, error) {
+	if text == "" {
+		return "", errors.New("empty habit name")
+	}
+	return text, nil
+}
+
+// parseDaysAnswer valida los días de un hábito ("lun,mie", "semana", ...)
+func parseDaysAnswer(text string) (string, error) {
+	_, err := habits.ParseWeekdays(text)
+	return strings.ToLower(text), err
+}
+
+// parseReminderAnswer valida una hora de recordatorio HH:MM
+func parseReminderAnswer(text string) (string, error) {
+	return text, habits.ValidateSchedule(nil, text)
+}
+
+// finishAddHabit crea el hábito con las respuestas del asistente de /addhabit
+func (b *Bot) finishAddHabit(ctx context.Context, message *tgbotapi.Message, data map[string]string) {
+	logger := logging.FromContext(ctx)
+	tr := i18n.FromContext(ctx)
+
+	habit, err := b.habitManager.AddHabit(data["name"], data["description"])
+	if err != nil {
+		logger.Error("Error adding habit", "error", err)
+		b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("addhabit.error", err)))
+		return
+	}
+
+	text := tr.T("addhabit.added", habit.ID, habit.Name)
+	if data["days"] != "" || data["reminder"] != "" {
+		// Las respuestas ya se validaron en cada paso
+		weekdays, _ := habits.ParseWeekdays(data["days"])
+		scheduled, err := b.habitManager.SetSchedule(habit.ID, weekdays, data["reminder"])
+		if err != nil {
+			logger.Error("Error setting habit schedule", "habit_id", habit.ID, "error", err)
+			b.send(tgbotapi.NewMessage(message.Chat.ID, tr.T("error", err)))
+			return
+		}
+		habit = scheduled
+	}
+	text += "\n" + tr.T("addhabit.days", tr.Weekdays(habit.Weekdays))
+	if habit.ReminderTime != "" {
+		text += "\n" + tr.T("addhabit.reminder", habit.ReminderTime)
+	}
+	b.send(tgbotapi.NewMessage(message.Chat.ID, text))
+}
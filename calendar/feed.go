@@ -0,0 +1,202 @@
+// Package calendar genera un feed iCalendar (.ics) con los días en que
+// corresponde cada hábito, para suscribirse desde cualquier cliente de calendario.
+package calendar
+
+import (
+	"fmt"
+	"habittracker/auth"
+	"habittracker/habits"
+	"habittracker/i18n"
+	"habittracker/settings"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ventana de días incluida en el feed, relativa a hoy
+const (
+	pastDays   = 30
+	futureDays = 90
+)
+
+// eventDuration es la duración de los eventos de hábitos con hora de recordatorio
+const eventDuration = 30 * time.Minute
+
+// Feed sirve el calendario de hábitos en una URL secreta por usuario
+type Feed struct {
+	habitManager *habits.HabitManager
+	tokens       *auth.TokenStore
+	users        *auth.UserStore
+	settings     *settings.Store
+	loc          *time.Location
+	now          func() time.Time
+}
+
+// NewFeed crea el feed. Los tokens se emiten con /calendar y forman parte de la URL.
+func NewFeed(habitManager *habits.HabitManager, tokens *auth.TokenStore, loc *time.Location) *Feed {
+	return &Feed{
+		habitManager: habitManager,
+		tokens:       tokens,
+		loc:          loc,
+		now:          time.Now,
+	}
+}
+
+// SetUsers sirve el feed solo a los dueños de los hábitos (administradores y
+// lista de la configuración): no a los aprobados con /approve ni a los bloqueados
+func (f *Feed) SetUsers(users *auth.UserStore) {
+	f.users = users
+}
+
+// SetSettings escribe el feed en el idioma de cada usuario
+func (f *Feed) SetSettings(store *settings.Store) {
+	f.settings = store
+}
+
+// localizer devuelve el idioma del dueño del feed
+func (f *Feed) localizer(userID int64) *i18n.Localizer {
+	if f.settings == nil {
+		return i18n.Get("")
+	}
+	s := f.settings.Get(userID)
+	if s.Language != "" {
+		return i18n.Get(s.Language)
+	}
+	return i18n.Get(s.DetectedLanguage)
+}
+
+// Handler devuelve el handler HTTP del feed (montar en /calendar/)
+func (f *Feed) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /calendar/{file}", f.serveFeed)
+	return mux
+}
+
+func (f *Feed) serveFeed(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID, err := f.tokens.Authenticate(token)
+	if err != nil || (f.users != nil && !f.users.OwnsHabits(userID)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	if err := f.Write(w, f.localizer(userID)); err != nil {
+		slog.Error("Error writing calendar feed", "user_id", userID, "error", err)
+	}
+}
+
+// Write escribe el calendario en el idioma de tr: un VEVENT por día y por hora
+// de recordatorio para los hábitos que la tienen y un VTODO por día para los
+// demás, con el estado de DailyLog
+func (f *Feed) Write(w io.Writer, tr *i18n.Localizer) error {
+	now := f.now().In(f.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, f.loc)
+	from := today.AddDate(0, 0, -pastDays)
+	to := today.AddDate(0, 0, futureDays)
+
+	logs := make(map[string]habits.DailyLog)
+	for _, log := range f.habitManager.GetDailyLogs(from.Format(habits.DateFormat), to.Format(habits.DateFormat), 0) {
+		logs[fmt.Sprintf("%s/%d", log.Date, log.HabitID)] = log
+	}
+
+	cw := &writer{w: w}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//HabitTracker//Habit Calendar//ES")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.prop("X-WR-CALNAME", tr.T("calendar.name"))
+	cw.line("X-WR-TIMEZONE:" + f.loc.String())
+	cw.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+
+	stamp := now.UTC().Format(utcFormat)
+	for _, habit := range f.habitManager.GetActiveHabits() {
+		reminders := habit.ReminderTimes()
+
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			date := d.Format(habits.DateFormat)
+			if !habit.DueOn(date) {
+				continue
+			}
+			log := logs[fmt.Sprintf("%s/%d", date, habit.ID)]
+			uid := fmt.Sprintf("habit-%d-%s@habittracker", habit.ID, date)
+
+			if len(reminders) > 0 {
+				summary := habit.Name
+				if log.Completed {
+					summary = "✅ " + summary
+				}
+				for _, hhmm := range reminders {
+					reminder, _ := time.Parse(habits.ReminderTimeFormat, hhmm)
+					start := time.Date(d.Year(), d.Month(), d.Day(), reminder.Hour(), reminder.Minute(), 0, 0, f.loc)
+					// Con varias horas, cada evento lleva la suya en el UID
+					eventUID := uid
+					if len(reminders) > 1 {
+						eventUID = fmt.Sprintf("habit-%d-%s-%s@habittracker", habit.ID, date, reminder.Format("1504"))
+					}
+					cw.line("BEGIN:VEVENT")
+					cw.line("UID:" + eventUID)
+					cw.line("DTSTAMP:" + stamp)
+					cw.line("DTSTART:" + start.UTC().Format(utcFormat))
+					cw.line("DTEND:" + start.Add(eventDuration).UTC().Format(utcFormat))
+					cw.prop("SUMMARY", summary)
+					cw.prop("DESCRIPTION", description(tr, habit, log))
+					cw.line("CATEGORIES:HABIT")
+					cw.line("TRANSP:TRANSPARENT")
+					cw.line("END:VEVENT")
+				}
+				continue
+			}
+
+			cw.line("BEGIN:VTODO")
+			cw.line("UID:" + uid)
+			cw.line("DTSTAMP:" + stamp)
+			cw.line("DTSTART;VALUE=DATE:" + d.Format(dateFormat))
+			cw.line("DUE;VALUE=DATE:" + d.AddDate(0, 0, 1).Format(dateFormat))
+			cw.prop("SUMMARY", habit.Name)
+			cw.prop("DESCRIPTION", description(tr, habit, log))
+			cw.line("CATEGORIES:HABIT")
+			if log.Completed {
+				cw.line("STATUS:COMPLETED")
+				cw.line("PERCENT-COMPLETE:100")
+				cw.line("COMPLETED:" + d.AddDate(0, 0, 1).Add(-time.Minute).UTC().Format(utcFormat))
+			} else {
+				cw.line("STATUS:NEEDS-ACTION")
+			}
+			cw.line("END:VTODO")
+		}
+	}
+
+	cw.line("END:VCALENDAR")
+	return cw.err
+}
+
+// description arma la descripción de un día: la del hábito y su estado
+func description(tr *i18n.Localizer, habit habits.Habit, log habits.DailyLog) string {
+	var parts []string
+	if habit.Description != "" {
+		parts = append(parts, habit.Description)
+	}
+	if log.Planned {
+		parts = append(parts, tr.T("calendar.planned"))
+	}
+	if log.Completed {
+		parts = append(parts, tr.T("calendar.completed"))
+	}
+	if log.Value != 0 {
+		parts = append(parts, tr.T("calendar.value", log.Value))
+	}
+	if log.Notes != "" {
+		parts = append(parts, tr.T("calendar.notes", log.Notes))
+	}
+	return strings.Join(parts, "\n")
+}
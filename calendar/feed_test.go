@@ -0,0 +1,146 @@
+package calendar
+
+import (
+	"habittracker/auth"
+	"habittracker/habits"
+	"habittracker/settings"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFeed prueba el feed: autenticación por URL, días según la frecuencia y estado completado
+func TestFeed(t *testing.T) {
+	dir := t.TempDir()
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	tokens, err := auth.NewTokenStore(filepath.Join(dir, "calendar_tokens.json"))
+	if err != nil {
+		t.Fatalf("Failed to create token store: %v", err)
+	}
+	token, _ := tokens.Issue(42)
+
+	loc, _ := time.LoadLocation("America/Argentina/Buenos_Aires")
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, loc) // miércoles
+
+	// Correr: lunes, miércoles y viernes a las 07:30; Leer: todos los días, sin hora
+	created := now.Add(-2 * time.Hour)
+	hm.Merge([]habits.Habit{
+		{ID: 1, Name: "Correr", Description: "5 km, sin apuro", CreatedAt: created},
+		{ID: 2, Name: "Leer", CreatedAt: created},
+	}, nil, false)
+	run, _ := hm.GetHabit(1)
+	read, _ := hm.GetHabit(2)
+	hm.SetSchedule(run.ID, []time.Weekday{time.Monday, time.Wednesday, time.Friday}, "07:30")
+	hm.UpsertDailyLog(habits.DailyLog{Date: "2024-03-06", HabitID: run.ID, Completed: true})
+	hm.UpsertDailyLog(habits.DailyLog{Date: "2024-03-06", HabitID: read.ID, Completed: true, Notes: "Cap. 3; fin"})
+
+	feed := NewFeed(hm, tokens, loc)
+	feed.now = func() time.Time { return now }
+	h := feed.Handler()
+
+	for _, path := range []string{"/calendar/ht_bogus.ics", "/calendar/" + token} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/calendar/"+token+".ics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Fatalf("Invalid calendar framing")
+	}
+	// Los hábitos se crearon hoy: solo hoy y los días futuros de la ventana
+	if got := strings.Count(body, "BEGIN:VTODO"); got != futureDays+1 {
+		t.Errorf("Expected %d todos, got %d", futureDays+1, got)
+	}
+	if got := strings.Count(body, "BEGIN:VEVENT"); got != 39 {
+		t.Errorf("Expected 39 events (3 per week), got %d", got)
+	}
+
+	for _, want := range []string{
+		"UID:habit-1-2024-03-06@habittracker\r\nDTSTAMP:20240306T150000Z\r\nDTSTART:20240306T103000Z\r\nDTEND:20240306T110000Z\r\nSUMMARY:✅ Correr\r\nDESCRIPTION:5 km\\, sin apuro\\nCompletado\r\n",
+		"UID:habit-2-2024-03-06@habittracker",
+		"DESCRIPTION:Completado\\nNotas: Cap. 3\\; fin\r\nCATEGORIES:HABIT\r\nSTATUS:COMPLETED\r\n",
+		"UID:habit-2-2024-03-07@habittracker\r\nDTSTAMP:20240306T150000Z\r\nDTSTART;VALUE=DATE:20240307\r\nDUE;VALUE=DATE:20240308\r\nSUMMARY:Leer\r\nCATEGORIES:HABIT\r\nSTATUS:NEEDS-ACTION\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected feed to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "habit-1-2024-03-07") {
+		t.Error("Correr must not be due on Thursday")
+	}
+}
+
+// TestFeedRemindersAndLanguage prueba un evento por cada hora de recordatorio
+// y los textos en el idioma del dueño del feed
+func TestFeedRemindersAndLanguage(t *testing.T) {
+	dir := t.TempDir()
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	tokens, _ := auth.NewTokenStore(filepath.Join(dir, "calendar_tokens.json"))
+	token, _ := tokens.Issue(42)
+	store, _ := settings.NewStore(filepath.Join(dir, "settings.json"))
+	store.Update(42, func(u *settings.User) { u.Language = "en" })
+
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	hm.Merge([]habits.Habit{{ID: 1, Name: "Stretch", CreatedAt: now}}, nil, false)
+	hm.SetSchedule(1, nil, "07:00,19:30")
+	hm.UpsertDailyLog(habits.DailyLog{Date: "2024-03-06", HabitID: 1, Planned: true})
+
+	feed := NewFeed(hm, tokens, time.UTC)
+	feed.SetSettings(store)
+	feed.now = func() time.Time { return now }
+
+	rec := httptest.NewRecorder()
+	feed.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/calendar/"+token+".ics", nil))
+	body := rec.Body.String()
+
+	if got := strings.Count(body, "BEGIN:VEVENT"); got != 2*(futureDays+1) {
+		t.Errorf("Expected 2 events per day, got %d", got)
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:Habits\r\n",
+		"UID:habit-1-2024-03-06-0700@habittracker\r\nDTSTAMP:20240306T120000Z\r\nDTSTART:20240306T070000Z\r\n",
+		"UID:habit-1-2024-03-06-1930@habittracker\r\nDTSTAMP:20240306T120000Z\r\nDTSTART:20240306T193000Z\r\n",
+		"DESCRIPTION:Planned\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected feed to contain %q", want)
+		}
+	}
+}
+
+// TestLineFolding prueba que las líneas largas se pliegan sin cortar caracteres UTF-8
+func TestLineFolding(t *testing.T) {
+	var b strings.Builder
+	cw := &writer{w: &b}
+	cw.prop("SUMMARY", strings.Repeat("ñ", 60))
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line longer than %d octets: %d", maxLineOctets, len(line))
+		}
+	}
+	unfolded := strings.ReplaceAll(b.String(), "\r\n ", "")
+	if unfolded != "SUMMARY:"+strings.Repeat("ñ", 60)+"\r\n" {
+		t.Errorf("Unexpected unfolded line: %q", unfolded)
+	}
+}
@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatos de fecha de iCalendar (RFC 5545)
+const (
+	dateFormat = "20060102"
+	utcFormat  = "20060102T150405Z"
+)
+
+// maxLineOctets es el largo máximo de una línea antes de plegarla
+const maxLineOctets = 75
+
+// writer escribe líneas de contenido iCalendar, conservando el primer error
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+// prop escribe una propiedad de texto, escapando su valor
+func (cw *writer) prop(name, value string) {
+	if value == "" {
+		return
+	}
+	cw.line(name + ":" + escapeText(value))
+}
+
+// line escribe una línea terminada en CRLF, plegada a 75 octetos sin cortar caracteres UTF-8
+func (cw *writer) line(s string) {
+	if cw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // el espacio inicial de la continuación cuenta
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+
+	_, cw.err = io.WriteString(cw.w, b.String())
+}
+
+// escapeText escapa un valor TEXT según RFC 5545
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
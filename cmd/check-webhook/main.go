@@ -2,65 +2,185 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"habittracker/bot"
 	"habittracker/config"
-	"log"
-	"net/http"
+	"habittracker/logging"
 	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// Script para verificar el estado del webhook en Telegram
+// Herramienta para inspeccionar y administrar el webhook en Telegram
 func main() {
+	global := flag.NewFlagSet("check-webhook", flag.ExitOnError)
+	jsonOutput := global.Bool("json", false, "print the result as JSON")
+	global.Usage = usage
+	global.Parse(os.Args[1:])
+
 	// Cargar configuración
 	if err := config.LoadConfig(); err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		logging.Fatal("Error loading config", "error", err)
 	}
 
-	token := config.AppConfig.TelegramBotToken
-
-	// Consultar información del webhook
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/getWebhookInfo", token)
-
-	resp, err := http.Get(url)
+	api, err := tgbotapi.NewBotAPI(config.AppConfig.TelegramBotToken)
 	if err != nil {
-		log.Fatalf("Error getting webhook info: %v", err)
+		logging.Fatal("Error connecting to Telegram", "error", err)
 	}
-	defer resp.Body.Close()
 
-	var result struct {
-		Ok     bool `json:"ok"`
-		Result struct {
-			URL                  string `json:"url"`
-			HasCustomCertificate bool   `json:"has_custom_certificate"`
-			PendingUpdateCount   int    `json:"pending_update_count"`
-			LastErrorDate        int    `json:"last_error_date,omitempty"`
-			LastErrorMessage     string `json:"last_error_message,omitempty"`
-			MaxConnections       int    `json:"max_connections,omitempty"`
-		} `json:"result"`
+	args := global.Args()
+	command := "info"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Fatalf("Error decoding response: %v", err)
+	switch command {
+	case "info":
+		err = runInfo(api, *jsonOutput)
+	case "set":
+		err = runSet(api, args, *jsonOutput)
+	case "delete":
+		err = runDelete(api, args, *jsonOutput)
+	case "help":
+		usage()
+	default:
+		usage()
+		os.Exit(2)
 	}
 
-	if !result.Ok {
-		fmt.Println("❌ Error getting webhook info")
+	if err != nil {
+		if *jsonOutput {
+			printJSON(map[string]any{"ok": false, "error": err.Error()})
+		} else {
+			fmt.Printf("❌ %v\n", err)
+		}
 		os.Exit(1)
 	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: check-webhook [--json] [command] [options]
+
+Commands:
+  info                Show the webhook status (default)
+  set [options]       Register the webhook
+      --url URL                   Webhook URL (default: WEBHOOK_URL)
+      --secret TOKEN              secret_token sent by Telegram (default: WEBHOOK_SECRET)
+      --allowed-updates LIST      Comma-separated update types, e.g. message,callback_query
+      --max-connections N         Maximum simultaneous connections (1-100)
+      --drop-pending-updates      Drop updates queued while no webhook was set
+  delete [options]    Remove the webhook (allows long polling again)
+      --drop-pending-updates      Drop pending updates
+`)
+}
+
+func runInfo(api *tgbotapi.BotAPI, jsonOutput bool) error {
+	status, err := bot.FetchWebhookStatus(api)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		printJSON(status)
+		return nil
+	}
 
 	fmt.Println("📡 Webhook Status:")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	if result.Result.URL == "" {
+	if !status.IsSet() {
 		fmt.Println("❌ No webhook configured (using long polling)")
+		fmt.Printf("⏳ Pending updates: %d\n", status.PendingUpdateCount)
+		return nil
+	}
+
+	fmt.Printf("✅ Webhook URL: %s\n", status.URL)
+	fmt.Printf("⏳ Pending updates: %d\n", status.PendingUpdateCount)
+	if status.MaxConnections > 0 {
+		fmt.Printf("🔌 Max connections: %d\n", status.MaxConnections)
+	}
+	if len(status.AllowedUpdates) > 0 {
+		fmt.Printf("📨 Allowed updates: %s\n", strings.Join(status.AllowedUpdates, ", "))
+	}
+	if status.IPAddress != "" {
+		fmt.Printf("🌐 IP address: %s\n", status.IPAddress)
+	}
+
+	if status.LastErrorTime != nil {
+		fmt.Printf("⚠️  Last error: %s\n", status.LastErrorMessage)
+		fmt.Printf("🕒 At: %s (%s ago)\n",
+			status.LastErrorTime.Local().Format(time.RFC1123),
+			time.Since(*status.LastErrorTime).Round(time.Second))
 	} else {
-		fmt.Printf("✅ Webhook URL: %s\n", result.Result.URL)
-		fmt.Printf("⏳ Pending updates: %d\n", result.Result.PendingUpdateCount)
+		fmt.Println("✅ No errors")
+	}
+	return nil
+}
 
-		if result.Result.LastErrorDate > 0 {
-			fmt.Printf("⚠️  Last error: %s\n", result.Result.LastErrorMessage)
-		} else {
-			fmt.Println("✅ No errors")
+func runSet(api *tgbotapi.BotAPI, args []string, jsonOutput bool) error {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	webhookURL := fs.String("url", config.AppConfig.WebhookURL, "webhook URL")
+	secret := fs.String("secret", config.AppConfig.WebhookSecret, "secret token")
+	allowed := fs.String("allowed-updates", "", "comma-separated update types")
+	maxConnections := fs.Int("max-connections", 0, "maximum simultaneous connections")
+	dropPending := fs.Bool("drop-pending-updates", false, "drop pending updates")
+	fs.Parse(args)
+
+	if *webhookURL == "" {
+		return fmt.Errorf("no webhook URL: pass --url or set WEBHOOK_URL")
+	}
+	if *maxConnections < 0 || *maxConnections > 100 {
+		return fmt.Errorf("--max-connections must be between 1 and 100")
+	}
+
+	opts := bot.WebhookOptions{
+		URL:                *webhookURL,
+		SecretToken:        *secret,
+		MaxConnections:     *maxConnections,
+		DropPendingUpdates: *dropPending,
+	}
+	for _, u := range strings.Split(*allowed, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			opts.AllowedUpdates = append(opts.AllowedUpdates, u)
 		}
 	}
+
+	if err := bot.ConfigureWebhook(api, opts); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return runInfo(api, true)
+	}
+	fmt.Printf("✅ Webhook set to %s\n", opts.URL)
+	if opts.SecretToken != "" {
+		fmt.Println("🔐 Secret token configured")
+	}
+	return nil
+}
+
+func runDelete(api *tgbotapi.BotAPI, args []string, jsonOutput bool) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	dropPending := fs.Bool("drop-pending-updates", false, "drop pending updates")
+	fs.Parse(args)
+
+	if err := bot.RemoveWebhook(api, *dropPending); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		printJSON(map[string]any{"ok": true})
+		return nil
+	}
+	fmt.Println("✅ Webhook deleted (long polling enabled)")
+	return nil
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
 }
@@ -0,0 +1,684 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"habittracker/backup"
+	"habittracker/config"
+	"habittracker/habits"
+	"habittracker/habits/importers"
+	"habittracker/i18n"
+	"habittracker/notes"
+	"habittracker/storage"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// habitctl administra los datos del Habit Tracker directamente sobre los archivos,
+// sin necesitar el token del bot. Detener el bot antes de modificar datos.
+func main() {
+	global := flag.NewFlagSet("habitctl", flag.ExitOnError)
+	dataDir := global.String("data", "data", "data directory")
+	global.Usage = usage
+	global.Parse(os.Args[1:])
+
+	args := global.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	// Las claves de cifrado se leen del entorno o del .env del bot, si existe
+	godotenv.Load()
+	keyring, err := storage.KeyringFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	storage.SetKeyring(keyring)
+
+	// Los días se cuentan en la zona horaria del bot, como en el bot
+	tz := os.Getenv("TIMEZONE")
+	if tz == "" {
+		tz = config.DefaultTimezone
+	}
+	if location, err = time.LoadLocation(tz); err != nil {
+		fail(fmt.Errorf("invalid TIMEZONE %q: %w", tz, err))
+	}
+
+	hm, loadErr := habits.OpenHabitManager(
+		filepath.Join(*dataDir, "habits.json"),
+		filepath.Join(*dataDir, "responses.json"),
+		filepath.Join(*dataDir, "daily_logs.json"),
+	)
+
+	// validate informa errores de carga y los backups no necesitan los datos cargados;
+	// el resto de los comandos no puede continuar
+	switch args[0] {
+	case "validate", "backup", "backups", "verify", "restore", "keygen", "encrypt", "decrypt":
+	default:
+		if loadErr != nil {
+			fail(fmt.Errorf("%w\n(run 'habitctl validate' for details)", loadErr))
+		}
+	}
+
+	switch args[0] {
+	case "habits":
+		err = runHabits(hm, args[1:])
+	case "logs":
+		err = runLogs(hm, args[1:])
+	case "stats":
+		err = runStats(hm, args[1:])
+	case "export":
+		err = runExport(hm, args[1:])
+	case "import":
+		err = runImport(hm, args[1:])
+	case "notes":
+		err = runNotes(hm, args[1:])
+	case "validate":
+		err = runValidate(hm, loadErr)
+	case "repair":
+		err = runRepair(hm)
+	case "migrate":
+		err = runMigrate(hm, args[1:])
+	case "backup", "backups":
+		err = runBackup(*dataDir, args[0], args[1:])
+	case "verify":
+		err = runVerify(args[1:])
+	case "restore":
+		err = runRestore(*dataDir, args[1:])
+	case "keygen":
+		err = runKeygen()
+	case "encrypt", "decrypt":
+		err = runEncrypt(*dataDir, args[0] == "encrypt")
+	case "help", "-h", "--help":
+		usage()
+	default:
+		err = fmt.Errorf("unknown command %q", args[0])
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+// location es la zona horaria del bot (TIMEZONE)
+var location = time.Local
+
+// now devuelve la hora actual en la zona horaria del bot
+func now() time.Time {
+	return time.Now().In(location)
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: habitctl [-data DIR] <command> [arguments]
+
+Habits:
+  habits list [-all]                             List habits (-all includes archived)
+  habits add [-description D] <name>             Add a habit
+  habits edit [-name N] [-description D] [-days D] [-reminder HH:MM] <id>
+                                                 Edit a habit (days: mon,wed,fri, daily, weekdays...)
+  habits archive <id>                            Archive a habit (keeps its history)
+  habits unarchive <id>                          Restore an archived habit
+
+Logs:
+  logs show [date]                               Show the logs for a day (default: today)
+  logs set [-planned] [-completed] [-value N] [-notes T] <date> <id>
+                                                 Create or replace the log for a day
+
+Data:
+  stats [-from YYYY-MM-DD] [-to YYYY-MM-DD]      Stats for all habits
+  export [-format csv|json] [-from] [-to] [-o F] Export habits and daily history (default: stdout)
+  import [-dry-run] <file>                       Import a Loop Habit Tracker backup (.zip/.csv)
+                                                 or a Habitica export (.json)
+  notes -dir D [-filename T] [-days N] [-read-back]
+                                                 Write Markdown daily notes (and apply checkbox edits)
+  validate                                       Check data file consistency
+  repair                                         Fix the issues reported by validate
+  migrate [-dry-run]                             Apply pending schema migrations
+
+Backups:
+  backup [-dir D]                                Create a backup now and apply retention
+  backups [-dir D]                               List backups
+  verify <file>                                  Check a backup against its manifest
+  restore [-yes] <file>                          Verify a backup and replace the data directory
+
+Encryption (ENCRYPTION_KEY / ENCRYPTION_KEY_FILE, ENCRYPTION_OLD_KEYS):
+  keygen                                         Generate a new encryption key
+  encrypt                                        Encrypt all data files with the current key
+                                                 (also re-encrypts files after a key rotation)
+  decrypt                                        Write all data files back as plaintext
+`)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	os.Exit(1)
+}
+
+func runHabits(hm *habits.HabitManager, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing habits subcommand (list, add, edit, archive, unarchive)")
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("habits list", flag.ExitOnError)
+		all := fs.Bool("all", false, "include archived habits")
+		fs.Parse(args[1:])
+
+		list := hm.GetActiveHabits()
+		if *all {
+			list = hm.GetHabits()
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED\tSTATUS\tDAYS\tREMINDER")
+		for _, h := range list {
+			status := "active"
+			if h.Archived {
+				status = "archived"
+			}
+			reminder := h.ReminderTime
+			if reminder == "" {
+				reminder = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", h.ID, h.Name, h.CreatedAt.Format(habits.DateFormat), status,
+				i18n.Get("en").Weekdays(h.Weekdays), reminder)
+		}
+		return w.Flush()
+
+	case "add":
+		fs := flag.NewFlagSet("habits add", flag.ExitOnError)
+		description := fs.String("description", "", "habit description")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: habits add [-description D] <name>")
+		}
+
+		habit, err := hm.AddHabit(fs.Arg(0), *description)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Added habit %d: %s\n", habit.ID, habit.Name)
+		return nil
+
+	case "edit":
+		fs := flag.NewFlagSet("habits edit", flag.ExitOnError)
+		name := fs.String("name", "", "new name")
+		description := fs.String("description", "", "new description")
+		days := fs.String("days", "", "weekdays, e.g. mon,wed,fri or daily")
+		reminder := fs.String("reminder", "", "reminder time HH:MM (\"none\" to clear)")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: habits edit [-name N] [-description D] [-days D] [-reminder HH:MM] <id>")
+		}
+
+		id, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid habit ID %q", fs.Arg(0))
+		}
+		habit, err := hm.GetHabit(id)
+		if err != nil {
+			return err
+		}
+
+		// Solo cambiar los campos indicados
+		newName, newDescription := habit.Name, habit.Description
+		newDays, newReminder := habit.Weekdays, habit.ReminderTime
+		var parseErr error
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				newName = *name
+			case "description":
+				newDescription = *description
+			case "days":
+				newDays, parseErr = habits.ParseWeekdays(*days)
+			case "reminder":
+				newReminder = *reminder
+				if newReminder == "none" {
+					newReminder = ""
+				}
+			}
+		})
+		if parseErr != nil {
+			return parseErr
+		}
+		if err := habits.ValidateSchedule(newDays, newReminder); err != nil {
+			return err
+		}
+
+		if _, err = hm.UpdateHabit(id, newName, newDescription); err != nil {
+			return err
+		}
+		habit, err = hm.SetSchedule(id, newDays, newReminder)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Updated habit %d: %s\n", habit.ID, habit.Name)
+		return nil
+
+	case "archive", "unarchive":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: habits %s <id>", args[0])
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid habit ID %q", args[1])
+		}
+
+		archived := args[0] == "archive"
+		if err := hm.SetArchived(id, archived); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Habit %d %sd\n", id, args[0])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown habits subcommand %q", args[0])
+	}
+}
+
+func runLogs(hm *habits.HabitManager, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing logs subcommand (show, set)")
+	}
+
+	switch args[0] {
+	case "show":
+		date := now().Format(habits.DateFormat)
+		if len(args) > 1 {
+			date = args[1]
+		}
+		if _, err := time.Parse(habits.DateFormat, date); err != nil {
+			return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", date)
+		}
+
+		names := habitNames(hm)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "📅 %s\n", date)
+		fmt.Fprintln(w, "ID\tHABIT\tPLANNED\tCOMPLETED")
+		for _, log := range hm.GetDailyLogs(date, date, 0) {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", log.HabitID, names[log.HabitID], yesNo(log.Planned), yesNo(log.Completed))
+		}
+		return w.Flush()
+
+	case "set":
+		fs := flag.NewFlagSet("logs set", flag.ExitOnError)
+		planned := fs.Bool("planned", false, "mark as planned")
+		completed := fs.Bool("completed", false, "mark as completed")
+		value := fs.Float64("value", 0, "recorded amount (minutes, pages, ...)")
+		notes := fs.String("notes", "", "free-form notes")
+		fs.Parse(args[1:])
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: logs set [-planned] [-completed] [-value N] [-notes T] <date> <habit-id>")
+		}
+
+		id, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid habit ID %q", fs.Arg(1))
+		}
+
+		entry := habits.DailyLog{Date: fs.Arg(0), HabitID: id, Planned: *planned, Completed: *completed, Value: *value, Notes: *notes}
+		if err := hm.UpsertDailyLog(entry); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Log saved for habit %d on %s (planned: %s, completed: %s)\n",
+			id, entry.Date, yesNo(entry.Planned), yesNo(entry.Completed))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown logs subcommand %q", args[0])
+	}
+}
+
+func runStats(hm *habits.HabitManager, args []string) error {
+	today := now()
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	from := fs.String("from", today.AddDate(0, 0, -29).Format(habits.DateFormat), "start date")
+	to := fs.String("to", today.Format(habits.DateFormat), "end date")
+	fs.Parse(args)
+
+	stats, err := hm.GetAllStats(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "📊 %s → %s\n", *from, *to)
+	fmt.Fprintln(w, "ID\tHABIT\tPLANNED\tCOMPLETED\tRATE\tSTREAK\tBEST")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d/%d\t%.0f%%\t%d\t%d\n",
+			s.HabitID, s.HabitName, s.DaysPlanned, s.DaysCompleted, s.EligibleDays,
+			s.CompletionRate*100, s.CurrentStreak, s.BestStreak)
+	}
+	return w.Flush()
+}
+
+func runExport(hm *habits.HabitManager, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", habits.ExportCSV, "output format (csv or json)")
+	from := fs.String("from", "", "start date (default: first habit creation)")
+	to := fs.String("to", "", "end date (default: today)")
+	output := fs.String("o", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	export, err := hm.Export(*from, *to, now())
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return export.Write(os.Stdout, *format)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, *format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Exported %d rows (%s → %s) to %s\n", len(export.Days), export.From, export.To, *output)
+	return nil
+}
+
+func runImport(hm *habits.HabitManager, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "show what would be imported without saving")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [-dry-run] <file>")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	imp, err := importers.Parse(filepath.Base(fs.Arg(0)), data)
+	if err != nil {
+		return err
+	}
+
+	result, err := hm.Merge(imp.Habits, imp.Logs, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	prefix := "✅ Imported"
+	if *dryRun {
+		prefix = "⏳ Would import"
+	}
+	fmt.Printf("%s from %s: %d new habit(s), %d existing, %d day(s) added, %d skipped\n",
+		prefix, imp.Source, result.HabitsAdded, result.HabitsMatched, result.LogsAdded, result.LogsSkipped)
+	return nil
+}
+
+func runNotes(hm *habits.HabitManager, args []string) error {
+	fs := flag.NewFlagSet("notes", flag.ExitOnError)
+	dir := fs.String("dir", "", "notes directory (e.g. an Obsidian vault folder)")
+	filename := fs.String("filename", notes.DefaultFilename, "file name template, e.g. {{.Year}}/{{.Date}}.md")
+	days := fs.Int("days", 7, "number of days to sync, ending today")
+	readBack := fs.Bool("read-back", false, "apply checkboxes changed in the notes before rewriting them")
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("usage: notes -dir D [-filename T] [-days N] [-read-back]")
+	}
+	if *days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+
+	syncer, err := notes.NewSyncer(hm, *dir, *filename)
+	if err != nil {
+		return err
+	}
+	updated, err := syncer.Sync(now(), *days, *readBack)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Wrote %d daily note(s) to %s\n", *days, *dir)
+	if *readBack {
+		fmt.Printf("🔄 %d log(s) updated from the notes\n", updated)
+	}
+	return nil
+}
+
+func runValidate(hm *habits.HabitManager, loadErr error) error {
+	if loadErr != nil {
+		fmt.Printf("❌ %v\n", loadErr)
+	}
+
+	issues := hm.Validate()
+	for _, issue := range issues {
+		fmt.Printf("⚠️  %s\n", issue)
+	}
+
+	if loadErr != nil {
+		return fmt.Errorf("data files could not be parsed; fix them by hand or restore a backup")
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issue(s) found; run 'habitctl repair' to fix them", len(issues))
+	}
+
+	fmt.Println("✅ Data is consistent")
+	return nil
+}
+
+func runRepair(hm *habits.HabitManager) error {
+	actions, err := hm.Repair()
+	for _, action := range actions {
+		fmt.Printf("🔧 %s\n", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		fmt.Println("✅ Nothing to repair")
+	}
+	return nil
+}
+
+func runMigrate(hm *habits.HabitManager, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "list pending migrations without applying them")
+	fs.Parse(args)
+
+	version, err := hm.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d (latest: %d)\n", version, habits.LatestSchemaVersion())
+
+	if *dryRun {
+		pending, err := hm.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			fmt.Printf("⏳ %d: %s\n", m.Version, m.Description)
+		}
+		if len(pending) == 0 {
+			fmt.Println("✅ Up to date")
+		}
+		return nil
+	}
+
+	applied, err := hm.Migrate()
+	for _, m := range applied {
+		fmt.Printf("✅ %d: %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("✅ Up to date")
+	}
+	return nil
+}
+
+func runBackup(dataDir, command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	dir := fs.String("dir", "backups", "backup directory")
+	daily := fs.Int("keep-daily", 7, "daily backups to keep")
+	weekly := fs.Int("keep-weekly", 4, "weekly backups to keep")
+	monthly := fs.Int("keep-monthly", 6, "monthly backups to keep")
+	fs.Parse(args)
+
+	m := backup.NewManager(dataDir, *dir, backup.Retention{Daily: *daily, Weekly: *weekly, Monthly: *monthly})
+
+	if command == "backup" {
+		path, removed, err := m.Run(now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created %s\n", path)
+		for _, r := range removed {
+			fmt.Printf("🗑  Pruned %s\n", r)
+		}
+		return nil
+	}
+
+	list, err := m.List()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATED\tSIZE\tFILE")
+	for _, b := range list {
+		fmt.Fprintf(w, "%s\t%d KB\t%s\n", b.CreatedAt.Format("2006-01-02 15:04:05"), (b.Size+1023)/1024, b.Path)
+	}
+	return w.Flush()
+}
+
+func runVerify(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: verify <file>")
+	}
+
+	manifest, err := backup.Verify(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Backup from %s is valid (%d files)\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"), len(manifest.Files))
+	return nil
+}
+
+func runRestore(dataDir string, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: restore [-yes] <file>")
+	}
+	file := fs.Arg(0)
+
+	manifest, err := backup.Verify(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backup from %s (%d files) will replace %s\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"), len(manifest.Files), dataDir)
+
+	if !*yes {
+		fmt.Print("Continue? [y/N] ")
+		var answer string
+		fmt.Scanln(&answer)
+		if answer != "y" && answer != "Y" {
+			return fmt.Errorf("restore cancelled")
+		}
+	}
+
+	// Los datos restaurados deben poder cargarse antes de reemplazar los actuales
+	previous, err := backup.Restore(file, dataDir, func(dir string) error {
+		_, err := habits.OpenHabitManager(
+			filepath.Join(dir, "habits.json"),
+			filepath.Join(dir, "responses.json"),
+			filepath.Join(dir, "daily_logs.json"),
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Restored %s\n", dataDir)
+	if previous != "" {
+		fmt.Printf("📁 Previous data kept in %s\n", previous)
+	}
+	return nil
+}
+
+func runKeygen() error {
+	key, err := storage.GenerateKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func runEncrypt(dataDir string, encrypt bool) error {
+	if !storage.Enabled() {
+		return fmt.Errorf("no encryption key configured (set ENCRYPTION_KEY or ENCRYPTION_KEY_FILE)")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		// ReadFile descifra con cualquier clave del keyring, incluidas las anteriores
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		if encrypt {
+			err = storage.WriteFile(file, data, info.Mode().Perm())
+		} else {
+			err = os.WriteFile(file, data, info.Mode().Perm())
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔐 %s\n", file)
+	}
+
+	if encrypt {
+		fmt.Printf("✅ %d file(s) encrypted with the current key\n", len(files))
+	} else {
+		fmt.Printf("✅ %d file(s) decrypted; remove ENCRYPTION_KEY before starting the bot\n", len(files))
+	}
+	return nil
+}
+
+func habitNames(hm *habits.HabitManager) map[int]string {
+	names := make(map[int]string)
+	for _, h := range hm.GetHabits() {
+		names[h.ID] = h.Name
+	}
+	return names
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
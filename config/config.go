@@ -2,7 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,13 +17,45 @@ type Config struct {
 	NotificationTime string // Deprecated: Use MorningTime and EveningTime
 	MorningTime      string
 	EveningTime      string
+	ReviewReminder   string // Recordatorio de la revisión nocturna sin responder (HH:MM, "off" para deshabilitarlo)
 	Timezone         string
 	WebhookURL       string
+	WebhookSecret    string // secret_token que Telegram envía en cada update del webhook
+	PublicURL        string // URL pública del servidor HTTP (enlaces al dashboard)
 	Port             string
+	MetricsToken     string // Token que exige /metrics (Authorization: Bearer); vacío: sin autenticación
+	LogLevel         string // debug, info, warn, error
+	LogFormat        string // text o json
+	LogDebug         bool   // Registrar datos personales (nombres, texto) sin redactar
+	DefaultLanguage  string // Idioma de los usuarios sin preferencia ni idioma de Telegram disponible
+	TemplatesDir     string // Plantillas de mensajes personalizadas (vacío: las embebidas)
+
+	ConversationTimeout time.Duration // Tiempo sin respuesta tras el cual vence un asistente
+	ReminderNudge       time.Duration // Insistencia si un recordatorio no se responde (0: deshabilitada)
+	QuietHoursMode      string        // Mensajes programados en horario de silencio: defer (diferirlos hasta el fin) o drop
+
+	DailyNotesDir      string        // Directorio de notas diarias en Markdown (vacío: deshabilitado)
+	DailyNotesFilename string        // Plantilla del nombre de archivo, relativa al directorio
+	DailyNotesInterval time.Duration // Cada cuánto se sincronizan las notas
+	DailyNotesDays     int           // Cuántos días hacia atrás se sincronizan
+	DailyNotesReadBack bool          // Aplicar las casillas marcadas a mano en las notas
+
+	AllowedUserIDs []int64 // Usuarios de Telegram con acceso sin pedir aprobación
+	AdminUserIDs   []int64 // Administradores: aprueban y bloquean usuarios
+
+	AdminChatID       int64  // Chat que puede pedir /backup y recibe los archivos
+	BackupDir         string // Directorio de backups
+	BackupTime        string // Hora del backup diario (HH:MM, "off" para deshabilitarlo)
+	BackupKeepDaily   int    // Backups diarios que se conservan
+	BackupKeepWeekly  int    // Backups semanales que se conservan
+	BackupKeepMonthly int    // Backups mensuales que se conservan
 }
 
 var AppConfig *Config
 
+// DefaultTimezone es la zona horaria cuando TIMEZONE no está configurada
+const DefaultTimezone = "America/Argentina/Buenos_Aires"
+
 // LoadConfig carga la configuración desde el archivo .env
 func LoadConfig() error {
 	// Cargar archivo .env
@@ -33,9 +69,27 @@ func LoadConfig() error {
 		NotificationTime: os.Getenv("NOTIFICATION_TIME"),
 		MorningTime:      os.Getenv("MORNING_TIME"),
 		EveningTime:      os.Getenv("EVENING_TIME"),
+		ReviewReminder:   os.Getenv("REVIEW_REMINDER_TIME"),
 		Timezone:         os.Getenv("TIMEZONE"),
 		WebhookURL:       os.Getenv("WEBHOOK_URL"),
+		WebhookSecret:    os.Getenv("WEBHOOK_SECRET"),
+		PublicURL:        os.Getenv("PUBLIC_URL"),
 		Port:             os.Getenv("PORT"),
+		MetricsToken:     os.Getenv("METRICS_TOKEN"),
+		LogLevel:         os.Getenv("LOG_LEVEL"),
+		LogFormat:        os.Getenv("LOG_FORMAT"),
+		LogDebug:         os.Getenv("LOG_DEBUG") == "true",
+		DefaultLanguage:  os.Getenv("DEFAULT_LANGUAGE"),
+		TemplatesDir:     os.Getenv("TEMPLATES_DIR"),
+
+		QuietHoursMode: os.Getenv("QUIET_HOURS_MODE"),
+
+		DailyNotesDir:      os.Getenv("DAILY_NOTES_DIR"),
+		DailyNotesFilename: os.Getenv("DAILY_NOTES_FILENAME"),
+		DailyNotesReadBack: os.Getenv("DAILY_NOTES_READ_BACK") == "true",
+
+		BackupDir:  os.Getenv("BACKUP_DIR"),
+		BackupTime: os.Getenv("BACKUP_TIME"),
 	}
 
 	// Validar configuración requerida
@@ -48,7 +102,7 @@ func LoadConfig() error {
 	}
 
 	if AppConfig.Timezone == "" {
-		AppConfig.Timezone = "America/Argentina/Buenos_Aires"
+		AppConfig.Timezone = DefaultTimezone
 	}
 
 	if AppConfig.MorningTime == "" {
@@ -59,9 +113,149 @@ func LoadConfig() error {
 		AppConfig.EveningTime = "21:00"
 	}
 
+	if AppConfig.ReviewReminder == "" {
+		AppConfig.ReviewReminder = "23:00"
+	}
+
 	if AppConfig.Port == "" {
 		AppConfig.Port = "8080"
 	}
 
+	// Por defecto la URL pública es el origen del webhook
+	if AppConfig.PublicURL == "" && AppConfig.WebhookURL != "" {
+		if u, err := url.Parse(AppConfig.WebhookURL); err == nil && u.Host != "" {
+			AppConfig.PublicURL = u.Scheme + "://" + u.Host
+		}
+	}
+
+	if AppConfig.LogLevel == "" {
+		AppConfig.LogLevel = "info"
+	}
+
+	if AppConfig.LogFormat == "" {
+		AppConfig.LogFormat = "text"
+	}
+
+	if AppConfig.DefaultLanguage == "" {
+		AppConfig.DefaultLanguage = "es"
+	}
+
+	if AppConfig.DailyNotesFilename == "" {
+		AppConfig.DailyNotesFilename = "{{.Date}}.md"
+	}
+
+	AppConfig.ConversationTimeout = 10 * time.Minute
+	if v := os.Getenv("CONVERSATION_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid CONVERSATION_TIMEOUT %q", v)
+		}
+		AppConfig.ConversationTimeout = d
+	}
+
+	AppConfig.ReminderNudge = 30 * time.Minute
+	if v := os.Getenv("REMINDER_NUDGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid REMINDER_NUDGE %q", v)
+		}
+		AppConfig.ReminderNudge = d
+	}
+
+	switch AppConfig.QuietHoursMode {
+	case "":
+		AppConfig.QuietHoursMode = "defer"
+	case "defer", "drop":
+	default:
+		return fmt.Errorf("invalid QUIET_HOURS_MODE %q (expected defer or drop)", AppConfig.QuietHoursMode)
+	}
+
+	AppConfig.DailyNotesInterval = 15 * time.Minute
+	if v := os.Getenv("DAILY_NOTES_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid DAILY_NOTES_INTERVAL: %w", err)
+		}
+		AppConfig.DailyNotesInterval = d
+	}
+
+	AppConfig.DailyNotesDays = 7
+	if v := os.Getenv("DAILY_NOTES_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid DAILY_NOTES_DAYS: %q", v)
+		}
+		AppConfig.DailyNotesDays = n
+	}
+
+	if v := os.Getenv("ADMIN_CHAT_ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid ADMIN_CHAT_ID: %q", v)
+		}
+		AppConfig.AdminChatID = id
+	}
+
+	var err error
+	if AppConfig.AllowedUserIDs, err = parseIDList("ALLOWED_USER_IDS"); err != nil {
+		return err
+	}
+	if AppConfig.AdminUserIDs, err = parseIDList("ADMIN_USER_IDS"); err != nil {
+		return err
+	}
+	// En un chat privado el ID del chat es el del usuario
+	if len(AppConfig.AdminUserIDs) == 0 && AppConfig.AdminChatID > 0 {
+		AppConfig.AdminUserIDs = []int64{AppConfig.AdminChatID}
+	}
+
+	if AppConfig.BackupDir == "" {
+		AppConfig.BackupDir = "backups"
+	}
+
+	if AppConfig.BackupTime == "" {
+		AppConfig.BackupTime = "03:00"
+	}
+
+	for _, keep := range []struct {
+		env    string
+		target *int
+		def    int
+	}{
+		{"BACKUP_KEEP_DAILY", &AppConfig.BackupKeepDaily, 7},
+		{"BACKUP_KEEP_WEEKLY", &AppConfig.BackupKeepWeekly, 4},
+		{"BACKUP_KEEP_MONTHLY", &AppConfig.BackupKeepMonthly, 6},
+	} {
+		*keep.target = keep.def
+		if v := os.Getenv(keep.env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s: %q", keep.env, v)
+			}
+			*keep.target = n
+		}
+	}
+
 	return nil
 }
+
+// AccessControl indica si el bot está en modo privado
+func (c *Config) AccessControl() bool {
+	return len(c.AllowedUserIDs) > 0 || len(c.AdminUserIDs) > 0
+}
+
+// parseIDList lee una lista de IDs de Telegram separados por comas
+func parseIDList(env string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(os.Getenv(env), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", env, part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,156 @@
+package habits
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formatos de exportación soportados
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// MaxExportDays es el rango máximo de una exportación, igual al de la API: la
+// exportación arma una fila por hábito por día
+const MaxExportDays = 3660
+
+// ExportRow es una fila de la exportación: un hábito en un día
+type ExportRow struct {
+	Date      string  `json:"date"`
+	HabitID   int     `json:"habit_id"`
+	HabitName string  `json:"habit_name"`
+	Planned   bool    `json:"planned"`
+	Completed bool    `json:"completed"`
+	Value     float64 `json:"value"`
+	Notes     string  `json:"notes"`
+	Unknown   bool    `json:"unknown"`
+}
+
+// Export es el contenido completo de una exportación
+type Export struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Habits     []Habit     `json:"habits"`
+	Days       []ExportRow `json:"days"`
+}
+
+// Export arma la exportación de todos los hábitos (incluidos los archivados) entre
+// from y to (YYYY-MM-DD), con una fila por hábito por día desde su creación.
+// Si from está vacío se usa la fecha de creación del hábito más antiguo; si to
+// está vacío, la fecha de now. Las fechas se cuentan en la zona horaria de now.
+// El rango no puede superar MaxExportDays.
+func (hm *HabitManager) Export(from, to string, now time.Time) (*Export, error) {
+	loc := now.Location()
+	if to == "" {
+		to = now.Format(DateFormat)
+	}
+	if from == "" {
+		from = hm.firstDate(now).In(loc).Format(DateFormat)
+	}
+
+	fromDate, err := time.Parse(DateFormat, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", from, err)
+	}
+	toDate, err := time.Parse(DateFormat, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", to, err)
+	}
+	if toDate.Before(fromDate) {
+		return nil, fmt.Errorf("invalid range: %s is before %s", to, from)
+	}
+	if toDate.Sub(fromDate) > MaxExportDays*24*time.Hour {
+		return nil, fmt.Errorf("invalid range: more than %d days", MaxExportDays)
+	}
+
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	logs := make(map[string]DailyLog)
+	for _, log := range hm.dailyLogs {
+		logs[log.Date+"/"+strconv.Itoa(log.HabitID)] = log
+	}
+
+	export := &Export{
+		ExportedAt: now,
+		From:       from,
+		To:         to,
+		Habits:     append([]Habit{}, hm.habits...),
+		Days:       []ExportRow{},
+	}
+
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		date := d.Format(DateFormat)
+		for _, habit := range hm.habits {
+			if habit.CreatedAt.In(loc).Format(DateFormat) > date {
+				continue
+			}
+			log := logs[date+"/"+strconv.Itoa(habit.ID)]
+			export.Days = append(export.Days, ExportRow{
+				Date:      date,
+				HabitID:   habit.ID,
+				HabitName: habit.Name,
+				Planned:   log.Planned,
+				Completed: log.Completed,
+				Value:     log.Value,
+				Notes:     log.Notes,
+				Unknown:   log.Unknown,
+			})
+		}
+	}
+
+	return export, nil
+}
+
+// Write escribe la exportación en el formato indicado (csv o json)
+func (e *Export) Write(w io.Writer, format string) error {
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(e)
+	case ExportCSV:
+		return e.writeCSV(w)
+	default:
+		return fmt.Errorf("unknown export format %q (expected csv or json)", format)
+	}
+}
+
+func (e *Export) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "habit_id", "habit_name", "planned", "completed", "value", "notes", "unknown"})
+	for _, row := range e.Days {
+		cw.Write([]string{
+			row.Date,
+			strconv.Itoa(row.HabitID),
+			row.HabitName,
+			strconv.FormatBool(row.Planned),
+			strconv.FormatBool(row.Completed),
+			strconv.FormatFloat(row.Value, 'f', -1, 64),
+			row.Notes,
+			strconv.FormatBool(row.Unknown),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// firstDate devuelve la fecha de creación del hábito más antiguo, o now
+func (hm *HabitManager) firstDate(now time.Time) time.Time {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	first := now
+	for _, habit := range hm.habits {
+		if !habit.CreatedAt.IsZero() && habit.CreatedAt.Before(first) {
+			first = habit.CreatedAt
+		}
+	}
+	return first
+}
@@ -0,0 +1,80 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestExport prueba que la exportación tiene una fila por hábito por día desde su creación
+func TestExport(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.habits = []Habit{
+		{ID: 1, Name: "Leer", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+		{ID: 2, Name: "Correr, trotar", CreatedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), Archived: true},
+	}
+	hm.dailyLogs = []DailyLog{
+		{Date: "2024-01-01", HabitID: 1, Planned: true, Completed: true, Value: 20, Notes: "capítulo 3"},
+		{Date: "2024-01-03", HabitID: 2, Completed: true, Value: 5.5},
+	}
+
+	export, err := hm.Export("", "2024-01-03", time.Now())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if export.From != "2024-01-01" {
+		t.Errorf("Expected default from to be the first creation date, got %s", export.From)
+	}
+	// Día 1: solo Leer; días 2 y 3: ambos hábitos
+	if len(export.Days) != 5 {
+		t.Fatalf("Expected 5 rows, got %d: %+v", len(export.Days), export.Days)
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, ExportCSV); err != nil {
+		t.Fatalf("CSV export failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected header and 5 rows, got %d", len(records))
+	}
+	if got := records[1]; got[2] != "Leer" || got[3] != "true" || got[5] != "20" || got[6] != "capítulo 3" {
+		t.Errorf("Unexpected first row: %v", got)
+	}
+	if got := records[5]; got[2] != "Correr, trotar" || got[4] != "true" || got[5] != "5.5" {
+		t.Errorf("Unexpected last row: %v", got)
+	}
+
+	buf.Reset()
+	if err := export.Write(&buf, ExportJSON); err != nil {
+		t.Fatalf("JSON export failed: %v", err)
+	}
+	var decoded Export
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(decoded.Habits) != 2 || len(decoded.Days) != 5 {
+		t.Errorf("Unexpected JSON export: %d habits, %d days", len(decoded.Habits), len(decoded.Days))
+	}
+
+	if _, err := hm.Export("2024-01-05", "2024-01-01", time.Now()); err == nil {
+		t.Error("Expected an error for an inverted range")
+	}
+	if _, err := hm.Export("2020-01-01", "9999-12-31", time.Now()); err == nil {
+		t.Error("Expected an error for a range that is too large")
+	}
+	if err := export.Write(&buf, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	// Sin to, la exportación termina hoy en la zona horaria de now
+	now := time.Now().In(time.FixedZone("UTC+14", 14*3600))
+	if export, err := hm.Export("2024-01-01", "", now); err != nil || export.To != now.Format(DateFormat) {
+		t.Errorf("Expected the export to end on %s", now.Format(DateFormat))
+	}
+}
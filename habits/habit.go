@@ -2,17 +2,30 @@ package habits
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"habittracker/storage"
 	"os"
+	"sort"
 	"sync"
 	"time"
 )
 
+// DateFormat es el formato de fecha usado en los logs diarios
+const DateFormat = "2006-01-02"
+
+// ErrNotFound se devuelve cuando un hábito no existe
+var ErrNotFound = errors.New("not found")
+
 type Habit struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Archived     bool           `json:"archived,omitempty"`
+	Weekdays     []time.Weekday `json:"weekdays,omitempty"`      // días en que corresponde; vacío: todos los días
+	ReminderTime string         `json:"reminder_time,omitempty"` // HH:MM, o varias separadas por comas; vacío: sin hora fija
+	Pauses       []Pause        `json:"pauses,omitempty"`        // períodos pausados (vacaciones, enfermedad)
 }
 
 type HabitResponse struct {
@@ -23,10 +36,13 @@ type HabitResponse struct {
 }
 
 type DailyLog struct {
-	Date      string `json:"date"`
-	HabitID   int    `json:"habit_id"`
-	Planned   bool   `json:"planned"`
-	Completed bool   `json:"completed"`
+	Date      string  `json:"date"`
+	HabitID   int     `json:"habit_id"`
+	Planned   bool    `json:"planned"`
+	Completed bool    `json:"completed"`
+	Value     float64 `json:"value,omitempty"` // cantidad registrada (minutos, páginas, ...)
+	Notes     string  `json:"notes,omitempty"`
+	Unknown   bool    `json:"unknown,omitempty"` // la revisión nocturna quedó sin respuesta
 }
 
 type HabitManager struct {
@@ -41,6 +57,13 @@ type HabitManager struct {
 }
 
 func NewHabitManager(habitsFile, responsesFile, dailyLogsFile string) *HabitManager {
+	hm, _ := OpenHabitManager(habitsFile, responsesFile, dailyLogsFile)
+	return hm
+}
+
+// OpenHabitManager crea el gestor y devuelve el primer error de carga de los archivos.
+// El gestor se devuelve siempre, con los datos que se hayan podido cargar.
+func OpenHabitManager(habitsFile, responsesFile, dailyLogsFile string) (*HabitManager, error) {
 	hm := &HabitManager{
 		habits:        []Habit{},
 		responses:     []HabitResponse{},
@@ -50,10 +73,18 @@ func NewHabitManager(habitsFile, responsesFile, dailyLogsFile string) *HabitMana
 		dailyLogsFile: dailyLogsFile,
 		nextID:        1,
 	}
-	hm.LoadHabits()
-	hm.LoadResponses()
-	hm.LoadDailyLogs()
-	return hm
+	return hm, errors.Join(
+		wrapLoadError(habitsFile, hm.LoadHabits()),
+		wrapLoadError(responsesFile, hm.LoadResponses()),
+		wrapLoadError(dailyLogsFile, hm.LoadDailyLogs()),
+	)
+}
+
+func wrapLoadError(file string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("loading %s: %w", file, err)
 }
 
 // AddHabit agrega un nuevo hábito
@@ -85,6 +116,36 @@ func (hm *HabitManager) GetHabits() []Habit {
 	return hm.habits
 }
 
+// GetActiveHabits devuelve los hábitos no archivados
+func (hm *HabitManager) GetActiveHabits() []Habit {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	active := []Habit{}
+	for _, habit := range hm.habits {
+		if !habit.Archived {
+			active = append(active, habit)
+		}
+	}
+	return active
+}
+
+// SetArchived archiva o restaura un hábito. Los hábitos archivados conservan
+// su historial pero no se incluyen en la planificación ni en la revisión.
+func (hm *HabitManager) SetArchived(id int, archived bool) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := range hm.habits {
+		if hm.habits[i].ID == id {
+			hm.habits[i].Archived = archived
+			return hm.saveHabits()
+		}
+	}
+
+	return fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
+
 // DeleteHabit elimina un hábito por ID
 func (hm *HabitManager) DeleteHabit(id int) error {
 	hm.mu.Lock()
@@ -97,7 +158,42 @@ func (hm *HabitManager) DeleteHabit(id int) error {
 		}
 	}
 
-	return fmt.Errorf("habit with ID %d not found", id)
+	return fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
+
+// GetHabit devuelve un hábito por ID
+func (hm *HabitManager) GetHabit(id int) (*Habit, error) {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	for _, habit := range hm.habits {
+		if habit.ID == id {
+			h := habit
+			return &h, nil
+		}
+	}
+
+	return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
+
+// UpdateHabit cambia el nombre y la descripción de un hábito
+func (hm *HabitManager) UpdateHabit(id int, name, description string) (*Habit, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := range hm.habits {
+		if hm.habits[i].ID == id {
+			hm.habits[i].Name = name
+			hm.habits[i].Description = description
+			if err := hm.saveHabits(); err != nil {
+				return nil, err
+			}
+			h := hm.habits[i]
+			return &h, nil
+		}
+	}
+
+	return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
 }
 
 // RecordResponse registra una respuesta para un hábito
@@ -119,15 +215,12 @@ func (hm *HabitManager) RecordResponse(habitID int, completed bool) error {
 	return hm.saveResponses()
 }
 
-// RecordPlan registra si un hábito fue planeado para hoy
-func (hm *HabitManager) RecordPlan(habitID int, planned bool) error {
+// RecordPlan registra si un hábito fue planeado para date (YYYY-MM-DD)
+func (hm *HabitManager) RecordPlan(date string, habitID int, planned bool) error {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
 
-	now := time.Now()
-	date := now.Format("2006-01-02")
-
-	// Buscar si ya existe un log para hoy
+	// Buscar si ya existe un log para ese día
 	for i, log := range hm.dailyLogs {
 		if log.Date == date && log.HabitID == habitID {
 			hm.dailyLogs[i].Planned = planned
@@ -147,18 +240,16 @@ func (hm *HabitManager) RecordPlan(habitID int, planned bool) error {
 	return hm.saveDailyLogs()
 }
 
-// RecordCompletion registra si un hábito fue completado hoy
-func (hm *HabitManager) RecordCompletion(habitID int, completed bool) error {
+// RecordCompletion registra si un hábito fue completado en date (YYYY-MM-DD)
+func (hm *HabitManager) RecordCompletion(date string, habitID int, completed bool) error {
 	hm.mu.Lock()
 	defer hm.mu.Unlock()
 
-	now := time.Now()
-	date := now.Format("2006-01-02")
-
-	// Buscar si ya existe un log para hoy
+	// Buscar si ya existe un log para ese día
 	for i, log := range hm.dailyLogs {
 		if log.Date == date && log.HabitID == habitID {
 			hm.dailyLogs[i].Completed = completed
+			hm.dailyLogs[i].Unknown = false
 			return hm.saveDailyLogs()
 		}
 	}
@@ -189,9 +280,66 @@ func (hm *HabitManager) GetDailyPlans(date string) []DailyLog {
 	return logs
 }
 
+// GetDailyLogs devuelve los logs entre from y to (inclusive, formato YYYY-MM-DD).
+// Si habitID es 0 se devuelven los logs de todos los hábitos.
+func (hm *HabitManager) GetDailyLogs(from, to string, habitID int) []DailyLog {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	logs := []DailyLog{}
+	for _, log := range hm.dailyLogs {
+		if log.Date < from || log.Date > to {
+			continue
+		}
+		if habitID != 0 && log.HabitID != habitID {
+			continue
+		}
+		logs = append(logs, log)
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].Date != logs[j].Date {
+			return logs[i].Date < logs[j].Date
+		}
+		return logs[i].HabitID < logs[j].HabitID
+	})
+	return logs
+}
+
+// UpsertDailyLog crea o reemplaza el log de un hábito para una fecha
+func (hm *HabitManager) UpsertDailyLog(entry DailyLog) error {
+	if _, err := time.Parse(DateFormat, entry.Date); err != nil {
+		return fmt.Errorf("invalid date %q: %w", entry.Date, err)
+	}
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	found := false
+	for _, habit := range hm.habits {
+		if habit.ID == entry.HabitID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("habit with ID %d %w", entry.HabitID, ErrNotFound)
+	}
+
+	for i, log := range hm.dailyLogs {
+		if log.Date == entry.Date && log.HabitID == entry.HabitID {
+			hm.dailyLogs[i] = entry
+			return hm.saveDailyLogs()
+		}
+	}
+
+	hm.dailyLogs = append(hm.dailyLogs, entry)
+	return hm.saveDailyLogs()
+}
+
 // LoadDailyLogs carga los logs diarios desde el archivo
 func (hm *HabitManager) LoadDailyLogs() error {
-	data, err := os.ReadFile(hm.dailyLogsFile)
+	data, err := storage.ReadFile(hm.dailyLogsFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -212,12 +360,12 @@ func (hm *HabitManager) saveDailyLogs() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(hm.dailyLogsFile, data, 0644)
+	return storage.WriteFile(hm.dailyLogsFile, data, 0600)
 }
 
 // LoadHabits carga los hábitos desde el archivo
 func (hm *HabitManager) LoadHabits() error {
-	data, err := os.ReadFile(hm.habitsFile)
+	data, err := storage.ReadFile(hm.habitsFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // Archivo no existe aún, está bien
@@ -245,7 +393,7 @@ func (hm *HabitManager) LoadHabits() error {
 
 // LoadResponses carga las respuestas desde el archivo
 func (hm *HabitManager) LoadResponses() error {
-	data, err := os.ReadFile(hm.responsesFile)
+	data, err := storage.ReadFile(hm.responsesFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -266,7 +414,7 @@ func (hm *HabitManager) saveHabits() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(hm.habitsFile, data, 0644)
+	return storage.WriteFile(hm.habitsFile, data, 0600)
 }
 
 // saveResponses guarda las respuestas en el archivo
@@ -275,5 +423,5 @@ func (hm *HabitManager) saveResponses() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(hm.responsesFile, data, 0644)
+	return storage.WriteFile(hm.responsesFile, data, 0600)
 }
@@ -0,0 +1,103 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"habittracker/habits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// habiticaExport es la parte usada del JSON de "Exportar datos" de Habitica
+type habiticaExport struct {
+	Tasks struct {
+		Habits []habiticaTask `json:"habits"`
+		Dailys []habiticaTask `json:"dailys"`
+	} `json:"tasks"`
+}
+
+type habiticaTask struct {
+	Text      string            `json:"text"`
+	Notes     string            `json:"notes"`
+	CreatedAt time.Time         `json:"createdAt"`
+	History   []habiticaHistory `json:"history"`
+}
+
+type habiticaHistory struct {
+	Date       habiticaTime `json:"date"`
+	Completed  *bool        `json:"completed"`
+	IsDue      *bool        `json:"isDue"`
+	ScoredUp   int          `json:"scoredUp"`
+	ScoredDown int          `json:"scoredDown"`
+}
+
+// habiticaTime acepta las fechas del historial de Habitica, que según la
+// antigüedad de la cuenta son milisegundos desde epoch o texto ISO 8601
+type habiticaTime struct {
+	time.Time
+}
+
+func (t *habiticaTime) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t.Time = time.UnixMilli(ms)
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("invalid Habitica date %s", data)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// ParseHabitica lee el JSON de datos de usuario de Habitica. Las tareas diarias se
+// importan con su historial de cumplimiento; los hábitos con los días que se
+// sumaron puntos positivos, usando la cantidad de veces como valor.
+func ParseHabitica(data []byte) (*Import, error) {
+	var export habiticaExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Habitica export: %w", err)
+	}
+	if export.Tasks.Habits == nil && export.Tasks.Dailys == nil {
+		return nil, fmt.Errorf("invalid Habitica export: no tasks found")
+	}
+
+	b := newBuilder(SourceHabitica)
+
+	for _, task := range export.Tasks.Dailys {
+		id := b.addHabit(habits.Habit{Name: task.Text, Description: task.Notes, CreatedAt: task.CreatedAt})
+		for _, h := range task.History {
+			if h.Date.IsZero() {
+				continue
+			}
+			log := habits.DailyLog{
+				Date:    h.Date.Local().Format(habits.DateFormat),
+				HabitID: id,
+				Planned: h.IsDue == nil || *h.IsDue,
+			}
+			if h.Completed != nil {
+				log.Completed = *h.Completed
+			}
+			b.addLog(log)
+		}
+	}
+
+	for _, task := range export.Tasks.Habits {
+		id := b.addHabit(habits.Habit{Name: task.Text, Description: task.Notes, CreatedAt: task.CreatedAt})
+		for _, h := range task.History {
+			if h.Date.IsZero() || h.ScoredUp <= 0 {
+				continue
+			}
+			b.addLog(habits.DailyLog{
+				Date:      h.Date.Local().Format(habits.DateFormat),
+				HabitID:   id,
+				Completed: true,
+				Value:     float64(h.ScoredUp),
+			})
+		}
+	}
+
+	return b.result(), nil
+}
@@ -0,0 +1,85 @@
+// Package importers convierte exportaciones de otras aplicaciones de hábitos
+// (Loop Habit Tracker, Habitica) en hábitos y logs diarios.
+package importers
+
+import (
+	"bytes"
+	"fmt"
+	"habittracker/habits"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fuentes soportadas
+const (
+	SourceLoop     = "loop"
+	SourceHabitica = "habitica"
+)
+
+// Import es el resultado de leer un archivo de otra aplicación. Los IDs de los
+// hábitos son locales al archivo y solo relacionan los logs con su hábito.
+type Import struct {
+	Source string
+	Habits []habits.Habit
+	Logs   []habits.DailyLog
+}
+
+// Parse detecta el formato del archivo por su nombre y contenido y lo convierte:
+// un backup .zip o un Checkmarks.csv de Loop, o el JSON de datos de Habitica.
+func Parse(name string, data []byte) (*Import, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return ParseLoopBackup(data)
+	case strings.EqualFold(filepath.Ext(name), ".csv"):
+		return ParseLoopCheckmarks(data)
+	case strings.EqualFold(filepath.Ext(name), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		return ParseHabitica(data)
+	default:
+		return nil, fmt.Errorf("unsupported file %q: expected a Loop backup (.zip or .csv) or a Habitica export (.json)", name)
+	}
+}
+
+// builder acumula hábitos y logs, fusionando los logs repetidos del mismo día
+type builder struct {
+	imp   *Import
+	index map[string]int
+}
+
+func newBuilder(source string) *builder {
+	return &builder{
+		imp:   &Import{Source: source, Habits: []habits.Habit{}, Logs: []habits.DailyLog{}},
+		index: make(map[string]int),
+	}
+}
+
+func (b *builder) addHabit(h habits.Habit) int {
+	h.ID = len(b.imp.Habits) + 1
+	b.imp.Habits = append(b.imp.Habits, h)
+	return h.ID
+}
+
+func (b *builder) addLog(log habits.DailyLog) {
+	key := fmt.Sprintf("%s/%d", log.Date, log.HabitID)
+	if i, ok := b.index[key]; ok {
+		existing := &b.imp.Logs[i]
+		existing.Planned = existing.Planned || log.Planned
+		existing.Completed = existing.Completed || log.Completed
+		existing.Value += log.Value
+		return
+	}
+	b.index[key] = len(b.imp.Logs)
+	b.imp.Logs = append(b.imp.Logs, log)
+}
+
+func (b *builder) result() *Import {
+	sort.SliceStable(b.imp.Logs, func(i, j int) bool {
+		return b.imp.Logs[i].Date < b.imp.Logs[j].Date
+	})
+	return b.imp
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse(habits.DateFormat, date)
+}
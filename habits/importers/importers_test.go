@@ -0,0 +1,142 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func loopBackup(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestParseLoopBackup prueba la lectura de un backup de Loop con un hábito de sí/no y uno numérico
+func TestParseLoopBackup(t *testing.T) {
+	data := loopBackup(t, map[string]string{
+		"Habits.csv": "Position,Name,Type,Question,Description,Archived?\n" +
+			"001,Meditar,0,¿Meditaste hoy?,,false\n" +
+			"002,Leer,1,,Páginas leídas,true\n",
+		"Checkmarks.csv": "Date,Meditar,Leer,\n" +
+			"2024-01-03,2,12.5,\n" +
+			"2024-01-02,1,0,\n" +
+			"2024-01-01,2,-1,\n",
+		"001 Meditar/Checkmarks.csv": "Date,Value\n2024-01-01,2\n",
+	})
+
+	imp, err := Parse("Loop Habits CSV 2024-01-03.zip", data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if imp.Source != SourceLoop || len(imp.Habits) != 2 {
+		t.Fatalf("Unexpected import: %+v", imp)
+	}
+	if h := imp.Habits[0]; h.Name != "Meditar" || h.Description != "¿Meditaste hoy?" || h.Archived {
+		t.Errorf("Unexpected first habit: %+v", h)
+	}
+	if h := imp.Habits[1]; h.Name != "Leer" || h.Description != "Páginas leídas" || !h.Archived {
+		t.Errorf("Unexpected second habit: %+v", h)
+	}
+
+	// Meditar: 01 y 03 (el 02 fue automático); Leer: solo el 03 con valor
+	if len(imp.Logs) != 3 {
+		t.Fatalf("Expected 3 logs, got %+v", imp.Logs)
+	}
+	if log := imp.Logs[0]; log.Date != "2024-01-01" || log.HabitID != 1 || !log.Completed {
+		t.Errorf("Unexpected first log: %+v", log)
+	}
+	last := imp.Logs[2]
+	if last.Date != "2024-01-03" || last.HabitID != 2 || last.Value != 12.5 || !last.Completed {
+		t.Errorf("Unexpected numerical log: %+v", last)
+	}
+
+	if _, err := Parse("backup.zip", loopBackup(t, map[string]string{"Habits.csv": ""})); err == nil {
+		t.Error("Expected an error for a backup without Checkmarks.csv")
+	}
+}
+
+// TestParseLoopBackupTooLarge prueba que un CSV que descomprimido supera el
+// límite se rechaza
+func TestParseLoopBackupTooLarge(t *testing.T) {
+	data := loopBackup(t, map[string]string{
+		"Checkmarks.csv": "Date,Meditar,\n" + strings.Repeat("2024-01-01,2,\n", maxZipFileSize/14+1),
+	})
+	if len(data) > maxZipFileSize/100 {
+		t.Fatalf("Expected a small compressed backup, got %d bytes", len(data))
+	}
+	if _, err := Parse("backup.zip", data); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Expected an error for a too large file, got %v", err)
+	}
+}
+
+// TestParseHabitica prueba la lectura de tareas diarias y hábitos de Habitica
+func TestParseHabitica(t *testing.T) {
+	data := []byte(`{
+		"tasks": {
+			"dailys": [{
+				"text": "Estirar",
+				"notes": "10 minutos",
+				"createdAt": "2024-01-01T08:00:00.000Z",
+				"history": [
+					{"date": 1704196800000, "value": 1, "isDue": true, "completed": true},
+					{"date": "2024-01-03T12:00:00.000Z", "value": 0.5, "isDue": true, "completed": false},
+					{"date": 1704369600000, "value": 0.5, "isDue": false}
+				]
+			}],
+			"habits": [{
+				"text": "Tomar agua",
+				"createdAt": "2024-01-01T08:00:00.000Z",
+				"history": [
+					{"date": 1704196800000, "value": 2, "scoredUp": 2, "scoredDown": 0},
+					{"date": 1704199800000, "value": 3, "scoredUp": 1, "scoredDown": 0},
+					{"date": 1704283200000, "value": 2, "scoredUp": 0, "scoredDown": 1}
+				]
+			}]
+		}
+	}`)
+
+	imp, err := Parse("user-data.json", data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if imp.Source != SourceHabitica || len(imp.Habits) != 2 {
+		t.Fatalf("Unexpected import: %+v", imp)
+	}
+	if h := imp.Habits[0]; h.Name != "Estirar" || h.Description != "10 minutos" || h.CreatedAt.IsZero() {
+		t.Errorf("Unexpected daily: %+v", h)
+	}
+
+	var daily, habit int
+	for _, log := range imp.Logs {
+		switch log.HabitID {
+		case 1:
+			daily++
+		case 2:
+			habit++
+			// Los dos registros del mismo día se fusionan sumando las veces
+			if log.Value != 3 || !log.Completed {
+				t.Errorf("Expected merged habit log with value 3, got %+v", log)
+			}
+		}
+	}
+	if daily != 3 || habit != 1 {
+		t.Errorf("Expected 3 daily logs and 1 habit log, got %d and %d", daily, habit)
+	}
+
+	if _, err := Parse("notes.txt", []byte("hola")); err == nil {
+		t.Error("Expected an error for an unsupported file")
+	}
+}
@@ -0,0 +1,209 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"habittracker/habits"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Valores de Checkmarks.csv de Loop Habit Tracker
+const (
+	loopUnknown   = -1
+	loopNo        = 0
+	loopYesAuto   = 1 // cumplido por la frecuencia del hábito, no marcado
+	loopYesManual = 2
+	loopSkip      = 3
+)
+
+// maxZipFileSize es el tamaño máximo descomprimido de cada CSV del backup,
+// para que un zip malicioso no agote la memoria
+const maxZipFileSize = 50 << 20
+
+// loopHabit son los datos de una fila de Habits.csv
+type loopHabit struct {
+	Description string
+	Numerical   bool
+	Archived    bool
+}
+
+// ParseLoopBackup lee el .zip que genera "Exportar como CSV" en Loop Habit Tracker.
+// Usa Habits.csv para descripciones y tipos, y Checkmarks.csv para el historial.
+func ParseLoopBackup(data []byte) (*Import, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Loop backup: %w", err)
+	}
+
+	var habitsCSV, checkmarksCSV []byte
+	for _, f := range zr.File {
+		switch path.Base(f.Name) {
+		case "Habits.csv":
+			if path.Dir(f.Name) == "." {
+				habitsCSV, err = readZipFile(f)
+			}
+		case "Checkmarks.csv":
+			if path.Dir(f.Name) == "." {
+				checkmarksCSV, err = readZipFile(f)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if checkmarksCSV == nil {
+		return nil, fmt.Errorf("invalid Loop backup: Checkmarks.csv not found")
+	}
+
+	var meta map[string]loopHabit
+	if habitsCSV != nil {
+		if meta, err = parseLoopHabits(habitsCSV); err != nil {
+			return nil, err
+		}
+	}
+	return parseLoopCheckmarks(checkmarksCSV, meta)
+}
+
+// ParseLoopCheckmarks lee un Checkmarks.csv de Loop: una columna de fecha y una por hábito.
+// Sin Habits.csv todos los hábitos se consideran de sí/no.
+func ParseLoopCheckmarks(data []byte) (*Import, error) {
+	return parseLoopCheckmarks(data, nil)
+}
+
+// parseLoopCheckmarks usa meta (de Habits.csv) para la descripción, el tipo y el archivado
+func parseLoopCheckmarks(data []byte, meta map[string]loopHabit) (*Import, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Loop checkmarks: %w", err)
+	}
+	if len(records) == 0 || len(records[0]) < 2 || !strings.EqualFold(strings.TrimSpace(records[0][0]), "Date") {
+		return nil, fmt.Errorf("invalid Loop checkmarks: expected a Date column followed by one column per habit")
+	}
+
+	b := newBuilder(SourceLoop)
+
+	// Loop agrega una coma final al encabezado: ignorar columnas sin nombre
+	header := records[0]
+	ids := make([]int, len(header))
+	numerical := make([]bool, len(header))
+	for col := 1; col < len(header); col++ {
+		name := strings.TrimSpace(header[col])
+		if name == "" {
+			continue
+		}
+		m := meta[name]
+		ids[col] = b.addHabit(habits.Habit{Name: name, Description: m.Description, Archived: m.Archived})
+		numerical[col] = m.Numerical
+	}
+
+	for _, record := range records[1:] {
+		if len(record) == 0 {
+			continue
+		}
+		date := strings.TrimSpace(record[0])
+		if _, err := parseDate(date); err != nil {
+			return nil, fmt.Errorf("invalid Loop checkmarks: bad date %q", date)
+		}
+
+		for col := 1; col < len(record) && col < len(header); col++ {
+			if ids[col] == 0 {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[col]), 64)
+			if err != nil {
+				continue
+			}
+
+			log := habits.DailyLog{Date: date, HabitID: ids[col]}
+			switch {
+			case numerical[col]:
+				if value <= 0 {
+					continue
+				}
+				log.Completed = true
+				log.Value = value
+			case value == loopYesManual:
+				log.Completed = true
+			default:
+				// Desconocido, no, saltado o cumplido solo por frecuencia: sin registro
+				continue
+			}
+			b.addLog(log)
+		}
+	}
+
+	return b.result(), nil
+}
+
+// parseLoopHabits lee Habits.csv, identificando las columnas por su encabezado
+// porque cambian entre versiones de Loop
+func parseLoopHabits(data []byte) (map[string]loopHabit, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Loop habits: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	meta := make(map[string]loopHabit)
+	for _, record := range records[1:] {
+		name := field(record, "name")
+		if name == "" {
+			continue
+		}
+		description := field(record, "description")
+		if description == "" {
+			description = field(record, "question")
+		}
+		kind := strings.ToLower(field(record, "type"))
+		meta[name] = loopHabit{
+			Description: description,
+			Numerical:   kind == "1" || kind == "numerical",
+			Archived:    strings.EqualFold(field(record, "archived?"), "true"),
+		}
+	}
+	return meta, nil
+}
+
+// readZipFile lee un archivo del zip, hasta maxZipFileSize descomprimido
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxZipFileSize {
+		return nil, fmt.Errorf("invalid Loop backup: %s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// El tamaño declarado en el zip puede ser falso
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipFileSize {
+		return nil, fmt.Errorf("invalid Loop backup: %s is too large", f.Name)
+	}
+	return data, nil
+}
@@ -0,0 +1,163 @@
+package habits
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Issue es un problema de consistencia encontrado en los datos
+type Issue struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// Validate revisa la consistencia de los datos cargados sin modificarlos
+func (hm *HabitManager) Validate() []Issue {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+
+	var issues []Issue
+	habitIDs := make(map[int]bool)
+
+	for _, habit := range hm.habits {
+		if habit.ID <= 0 {
+			issues = append(issues, Issue{hm.habitsFile, fmt.Sprintf("habit %q has invalid ID %d", habit.Name, habit.ID)})
+		}
+		if habitIDs[habit.ID] {
+			issues = append(issues, Issue{hm.habitsFile, fmt.Sprintf("duplicate habit ID %d", habit.ID)})
+		}
+		habitIDs[habit.ID] = true
+
+		if strings.TrimSpace(habit.Name) == "" {
+			issues = append(issues, Issue{hm.habitsFile, fmt.Sprintf("habit %d has an empty name", habit.ID)})
+		}
+		if habit.CreatedAt.IsZero() {
+			issues = append(issues, Issue{hm.habitsFile, fmt.Sprintf("habit %d has no creation date", habit.ID)})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, log := range hm.dailyLogs {
+		if _, err := time.Parse(DateFormat, log.Date); err != nil {
+			issues = append(issues, Issue{hm.dailyLogsFile, fmt.Sprintf("log for habit %d has invalid date %q", log.HabitID, log.Date)})
+		}
+		if !habitIDs[log.HabitID] {
+			issues = append(issues, Issue{hm.dailyLogsFile, fmt.Sprintf("log on %s references unknown habit %d", log.Date, log.HabitID)})
+		}
+		key := fmt.Sprintf("%s/%d", log.Date, log.HabitID)
+		if seen[key] {
+			issues = append(issues, Issue{hm.dailyLogsFile, fmt.Sprintf("duplicate log for habit %d on %s", log.HabitID, log.Date)})
+		}
+		seen[key] = true
+	}
+
+	for _, response := range hm.responses {
+		if !habitIDs[response.HabitID] {
+			issues = append(issues, Issue{hm.responsesFile, fmt.Sprintf("response on %s references unknown habit %d", response.Date, response.HabitID)})
+		}
+	}
+
+	return issues
+}
+
+// Repair corrige los problemas que detecta Validate y guarda los archivos.
+// Devuelve la lista de cambios realizados.
+func (hm *HabitManager) Repair() ([]string, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	var actions []string
+
+	// Hábitos: IDs inválidos o duplicados reciben un ID nuevo
+	habitIDs := make(map[int]bool)
+	for _, habit := range hm.habits {
+		if habit.ID >= hm.nextID {
+			hm.nextID = habit.ID + 1
+		}
+	}
+	for i := range hm.habits {
+		h := &hm.habits[i]
+		if h.ID <= 0 || habitIDs[h.ID] {
+			old := h.ID
+			h.ID = hm.nextID
+			hm.nextID++
+			actions = append(actions, fmt.Sprintf("reassigned habit %q from ID %d to %d", h.Name, old, h.ID))
+		}
+		habitIDs[h.ID] = true
+
+		if strings.TrimSpace(h.Name) == "" {
+			h.Name = fmt.Sprintf("Habit %d", h.ID)
+			actions = append(actions, fmt.Sprintf("named habit %d %q", h.ID, h.Name))
+		}
+		if h.CreatedAt.IsZero() {
+			h.CreatedAt = hm.firstLogDate(h.ID)
+			actions = append(actions, fmt.Sprintf("set creation date of habit %d to %s", h.ID, h.CreatedAt.Format(DateFormat)))
+		}
+	}
+
+	// Logs: descartar fechas inválidas y huérfanos, fusionar duplicados
+	logs := []DailyLog{}
+	index := make(map[string]int)
+	for _, log := range hm.dailyLogs {
+		if _, err := time.Parse(DateFormat, log.Date); err != nil {
+			actions = append(actions, fmt.Sprintf("dropped log for habit %d with invalid date %q", log.HabitID, log.Date))
+			continue
+		}
+		if !habitIDs[log.HabitID] {
+			actions = append(actions, fmt.Sprintf("dropped log on %s for unknown habit %d", log.Date, log.HabitID))
+			continue
+		}
+		key := fmt.Sprintf("%s/%d", log.Date, log.HabitID)
+		if i, ok := index[key]; ok {
+			logs[i].Planned = logs[i].Planned || log.Planned
+			logs[i].Completed = logs[i].Completed || log.Completed
+			if logs[i].Value == 0 {
+				logs[i].Value = log.Value
+			}
+			if logs[i].Notes == "" {
+				logs[i].Notes = log.Notes
+			}
+			actions = append(actions, fmt.Sprintf("merged duplicate log for habit %d on %s", log.HabitID, log.Date))
+			continue
+		}
+		index[key] = len(logs)
+		logs = append(logs, log)
+	}
+	hm.dailyLogs = logs
+
+	responses := []HabitResponse{}
+	for _, response := range hm.responses {
+		if !habitIDs[response.HabitID] {
+			actions = append(actions, fmt.Sprintf("dropped response on %s for unknown habit %d", response.Date, response.HabitID))
+			continue
+		}
+		responses = append(responses, response)
+	}
+	hm.responses = responses
+
+	if len(actions) == 0 {
+		return nil, nil
+	}
+
+	return actions, errors.Join(hm.saveHabits(), hm.saveDailyLogs(), hm.saveResponses())
+}
+
+// firstLogDate devuelve la fecha del primer log de un hábito, o la fecha actual
+func (hm *HabitManager) firstLogDate(habitID int) time.Time {
+	first := time.Now()
+	for _, log := range hm.dailyLogs {
+		if log.HabitID != habitID {
+			continue
+		}
+		if d, err := time.ParseInLocation(DateFormat, log.Date, time.Local); err == nil && d.Before(first) {
+			first = d
+		}
+	}
+	return first
+}
@@ -0,0 +1,140 @@
+package habits
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T) (*HabitManager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	hm := NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	return hm, dir
+}
+
+// TestValidateAndRepair prueba la detección y corrección de datos inconsistentes
+func TestValidateAndRepair(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.AddHabit("Leer", "")
+
+	// Inyectar datos inconsistentes como si vinieran de archivos editados a mano
+	hm.habits = append(hm.habits, Habit{ID: 1, Name: "", CreatedAt: time.Now()})
+	hm.dailyLogs = []DailyLog{
+		{Date: "2024-01-01", HabitID: 1, Planned: true},
+		{Date: "2024-01-01", HabitID: 1, Completed: true},
+		{Date: "2024-13-01", HabitID: 1},
+		{Date: "2024-01-02", HabitID: 99},
+	}
+
+	if issues := hm.Validate(); len(issues) != 5 {
+		t.Fatalf("Expected 5 issues, got %d: %v", len(issues), issues)
+	}
+
+	if _, err := hm.Repair(); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	if issues := hm.Validate(); len(issues) != 0 {
+		t.Fatalf("Expected no issues after repair, got %v", issues)
+	}
+
+	logs := hm.GetDailyLogs("2024-01-01", "2024-01-01", 1)
+	if len(logs) != 1 || !logs[0].Planned || !logs[0].Completed {
+		t.Errorf("Expected duplicate logs to be merged, got %+v", logs)
+	}
+	if hm.habits[1].ID == 1 || hm.habits[1].Name == "" {
+		t.Errorf("Expected duplicate habit to get a new ID and a name, got %+v", hm.habits[1])
+	}
+}
+
+// TestMigrateLegacyResponses prueba que las respuestas antiguas pasan a logs diarios
+func TestMigrateLegacyResponses(t *testing.T) {
+	hm, dir := newTestManager(t)
+	hm.AddHabit("Meditar", "")
+
+	now := time.Now()
+	hm.responses = []HabitResponse{
+		{HabitID: 1, Completed: false, Date: "2024-01-01", Timestamp: now},
+		{HabitID: 1, Completed: true, Date: "2024-01-01", Timestamp: now.Add(time.Minute)},
+		{HabitID: 1, Completed: true, Date: "2024-01-02", Timestamp: now},
+	}
+	hm.dailyLogs = []DailyLog{{Date: "2024-01-02", HabitID: 1, Planned: true, Completed: false}}
+
+	applied, err := hm.Migrate()
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != LatestSchemaVersion() {
+		t.Errorf("Expected %d migrations applied, got %d", LatestSchemaVersion(), len(applied))
+	}
+
+	logs := hm.GetDailyLogs("2024-01-01", "2024-01-02", 1)
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 logs, got %+v", logs)
+	}
+	if !logs[0].Completed {
+		t.Error("Expected the latest response of the day to win")
+	}
+	if logs[1].Completed {
+		t.Error("Expected existing daily log not to be overwritten")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, schemaFile)); err != nil {
+		t.Errorf("Expected schema file to be written: %v", err)
+	}
+
+	// Una segunda ejecución no hace nada
+	applied, err = hm.Migrate()
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected no pending migrations, got %v (%v)", applied, err)
+	}
+}
+
+// TestStreaks prueba el cálculo de rachas
+func TestStreaks(t *testing.T) {
+	completed := map[string]bool{
+		"2024-01-01": true,
+		"2024-01-02": true,
+		"2024-01-03": true,
+		"2024-01-05": true,
+		"2024-01-06": true,
+	}
+
+	end, _ := time.Parse(DateFormat, "2024-01-07")
+	current, best := streaks(completed, nil, end)
+	if current != 2 || best != 3 {
+		t.Errorf("Expected current 2 and best 3, got %d and %d", current, best)
+	}
+}
+
+// TestDataFilesArePrivate prueba que los archivos de datos solo los lee su dueño
+func TestDataFilesArePrivate(t *testing.T) {
+	hm, dir := newTestManager(t)
+	habit, _ := hm.AddHabit("Leer", "")
+	if err := hm.RecordCompletion(time.Now().Format(DateFormat), habit.ID, true); err != nil {
+		t.Fatalf("RecordCompletion failed: %v", err)
+	}
+	if _, err := hm.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) < 3 {
+		t.Fatalf("Expected the habits, logs and schema files, got %v", files)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s: expected mode 0600, got %o", filepath.Base(file), info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package habits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MergeResult resume lo que hizo Merge
+type MergeResult struct {
+	HabitsAdded   int `json:"habits_added"`
+	HabitsMatched int `json:"habits_matched"`
+	LogsAdded     int `json:"logs_added"`
+	LogsSkipped   int `json:"logs_skipped"`
+}
+
+// Merge incorpora hábitos y logs traídos de otra fuente. Los IDs de entrada solo
+// relacionan los logs con sus hábitos: los hábitos se deduplican por nombre (sin
+// distinguir mayúsculas) y los logs por hábito y fecha, sin pisar logs existentes.
+// Con dryRun calcula el resultado sin modificar los datos.
+func (hm *HabitManager) Merge(in []Habit, logs []DailyLog, dryRun bool) (*MergeResult, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	result := &MergeResult{}
+	habits := append([]Habit{}, hm.habits...)
+	nextID := hm.nextID
+
+	byName := make(map[string]int)
+	for i, habit := range habits {
+		byName[normalizeName(habit.Name)] = i
+	}
+
+	// Fecha del primer log de cada hábito importado, para no perder historial en las estadísticas
+	firstLog := make(map[int]time.Time)
+	for _, log := range logs {
+		d, err := time.ParseInLocation(DateFormat, log.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", log.Date, err)
+		}
+		if first, ok := firstLog[log.HabitID]; !ok || d.Before(first) {
+			firstLog[log.HabitID] = d
+		}
+	}
+
+	ids := make(map[int]int)
+	for _, habit := range in {
+		name := strings.TrimSpace(habit.Name)
+		if name == "" {
+			continue
+		}
+
+		created := habit.CreatedAt
+		if first, ok := firstLog[habit.ID]; ok && (created.IsZero() || first.Before(created)) {
+			created = first
+		}
+
+		if i, ok := byName[normalizeName(name)]; ok {
+			if !created.IsZero() && created.Before(habits[i].CreatedAt) {
+				habits[i].CreatedAt = created
+			}
+			ids[habit.ID] = habits[i].ID
+			result.HabitsMatched++
+			continue
+		}
+
+		if created.IsZero() {
+			created = time.Now()
+		}
+		byName[normalizeName(name)] = len(habits)
+		habits = append(habits, Habit{
+			ID:          nextID,
+			Name:        name,
+			Description: habit.Description,
+			CreatedAt:   created,
+			Archived:    habit.Archived,
+		})
+		ids[habit.ID] = nextID
+		nextID++
+		result.HabitsAdded++
+	}
+
+	dailyLogs := append([]DailyLog{}, hm.dailyLogs...)
+	existing := make(map[string]bool)
+	for _, log := range dailyLogs {
+		existing[log.Date+"/"+strconv.Itoa(log.HabitID)] = true
+	}
+	for _, log := range logs {
+		id, ok := ids[log.HabitID]
+		if !ok {
+			result.LogsSkipped++
+			continue
+		}
+		key := log.Date + "/" + strconv.Itoa(id)
+		if existing[key] {
+			result.LogsSkipped++
+			continue
+		}
+		existing[key] = true
+		log.HabitID = id
+		dailyLogs = append(dailyLogs, log)
+		result.LogsAdded++
+	}
+
+	if dryRun || (result.HabitsAdded == 0 && result.HabitsMatched == 0 && result.LogsAdded == 0) {
+		return result, nil
+	}
+
+	hm.habits = habits
+	hm.nextID = nextID
+	hm.dailyLogs = dailyLogs
+	if err := hm.saveHabits(); err != nil {
+		return nil, err
+	}
+	if err := hm.saveDailyLogs(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
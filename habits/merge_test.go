@@ -0,0 +1,57 @@
+package habits
+
+import (
+	"testing"
+)
+
+// TestMerge prueba que Merge deduplica hábitos por nombre y logs por fecha
+func TestMerge(t *testing.T) {
+	hm, _ := newTestManager(t)
+	existing, _ := hm.AddHabit("Leer", "")
+	hm.UpsertDailyLog(DailyLog{Date: "2024-01-02", HabitID: existing.ID, Planned: true})
+
+	in := []Habit{{ID: 1, Name: " leer "}, {ID: 2, Name: "Correr"}}
+	logs := []DailyLog{
+		{Date: "2024-01-01", HabitID: 1, Completed: true},
+		{Date: "2024-01-02", HabitID: 1, Completed: true}, // ya existe
+		{Date: "2024-01-03", HabitID: 2, Completed: true, Value: 5},
+	}
+
+	dry, err := hm.Merge(in, logs, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(hm.GetHabits()) != 1 {
+		t.Fatalf("Dry run must not modify data")
+	}
+
+	result, err := hm.Merge(in, logs, false)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	want := MergeResult{HabitsAdded: 1, HabitsMatched: 1, LogsAdded: 2, LogsSkipped: 1}
+	if *result != want || *dry != want {
+		t.Errorf("Expected %+v, got %+v (dry run %+v)", want, *result, *dry)
+	}
+
+	// El hábito existente adopta la fecha del primer log importado
+	leer, _ := hm.GetHabit(existing.ID)
+	if got := leer.CreatedAt.Format(DateFormat); got != "2024-01-01" {
+		t.Errorf("Expected creation date 2024-01-01, got %s", got)
+	}
+	if logs := hm.GetDailyLogs("2024-01-02", "2024-01-02", existing.ID); len(logs) != 1 || logs[0].Completed {
+		t.Errorf("Existing log must not be overwritten, got %+v", logs)
+	}
+
+	// Importar de nuevo no duplica nada
+	again, err := hm.Merge(in, logs, false)
+	if err != nil {
+		t.Fatalf("Second merge failed: %v", err)
+	}
+	if again.HabitsAdded != 0 || again.LogsAdded != 0 {
+		t.Errorf("Expected nothing new on the second merge, got %+v", again)
+	}
+	if n := len(hm.GetHabits()); n != 2 {
+		t.Errorf("Expected 2 habits, got %d", n)
+	}
+}
@@ -0,0 +1,172 @@
+package habits
+
+import (
+	"encoding/json"
+	"habittracker/storage"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// schemaFile guarda la versión de esquema de los datos, junto a habits.json
+const schemaFile = "schema.json"
+
+// Migration es un cambio versionado sobre los datos guardados
+type Migration struct {
+	Version     int
+	Description string
+	apply       func(hm *HabitManager) error
+}
+
+// migrations es la lista ordenada de migraciones. Agregar nuevas al final.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "import legacy responses.json answers into daily logs",
+		apply:       migrateLegacyResponses,
+	},
+	{
+		Version:     2,
+		Description: "backfill missing habit creation dates from their first log",
+		apply:       migrateCreatedAt,
+	},
+}
+
+// schemaState es el contenido de schema.json
+type schemaState struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LatestSchemaVersion devuelve la versión de esquema más reciente
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion devuelve la versión de esquema de los datos (0 si nunca se migraron)
+func (hm *HabitManager) SchemaVersion() (int, error) {
+	data, err := storage.ReadFile(hm.schemaPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var state schemaState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, err
+	}
+	return state.Version, nil
+}
+
+// PendingMigrations devuelve las migraciones aún no aplicadas
+func (hm *HabitManager) PendingMigrations() ([]Migration, error) {
+	version, err := hm.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate aplica las migraciones pendientes en orden y guarda la nueva versión
+// después de cada una. Devuelve las migraciones aplicadas.
+func (hm *HabitManager) Migrate() ([]Migration, error) {
+	pending, err := hm.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := m.apply(hm); err != nil {
+			return applied, err
+		}
+		if err := hm.saveSchemaVersion(m.Version); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func (hm *HabitManager) schemaPath() string {
+	return filepath.Join(filepath.Dir(hm.habitsFile), schemaFile)
+}
+
+func (hm *HabitManager) saveSchemaVersion(version int) error {
+	data, err := json.MarshalIndent(schemaState{Version: version, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(hm.schemaPath(), data, 0600)
+}
+
+// migrateLegacyResponses copia las respuestas del flujo anterior (responses.json)
+// a los logs diarios, sin pisar logs existentes del mismo día.
+func migrateLegacyResponses(hm *HabitManager) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	existing := make(map[string]bool)
+	for _, log := range hm.dailyLogs {
+		existing[log.Date+"/"+strconv.Itoa(log.HabitID)] = true
+	}
+
+	// La última respuesta del día es la que vale
+	latest := make(map[string]HabitResponse)
+	var order []string
+	for _, response := range hm.responses {
+		key := response.Date + "/" + strconv.Itoa(response.HabitID)
+		if existing[key] {
+			continue
+		}
+		if _, ok := latest[key]; !ok {
+			order = append(order, key)
+		}
+		if prev, ok := latest[key]; !ok || !response.Timestamp.Before(prev.Timestamp) {
+			latest[key] = response
+		}
+	}
+
+	if len(order) == 0 {
+		return nil
+	}
+
+	for _, key := range order {
+		response := latest[key]
+		hm.dailyLogs = append(hm.dailyLogs, DailyLog{
+			Date:      response.Date,
+			HabitID:   response.HabitID,
+			Completed: response.Completed,
+		})
+	}
+	return hm.saveDailyLogs()
+}
+
+// migrateCreatedAt completa la fecha de creación de hábitos que no la tienen
+func migrateCreatedAt(hm *HabitManager) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	changed := false
+	for i := range hm.habits {
+		if hm.habits[i].CreatedAt.IsZero() {
+			hm.habits[i].CreatedAt = hm.firstLogDate(hm.habits[i].ID)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return hm.saveHabits()
+}
@@ -0,0 +1,128 @@
+package habits
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotPaused se devuelve al reanudar un hábito que no está pausado
+var ErrNotPaused = errors.New("not paused")
+
+// Pause es un período en que un hábito está pausado (vacaciones, enfermedad):
+// no se envían recordatorios y los días no cuentan para las rachas ni para el
+// porcentaje de cumplimiento
+type Pause struct {
+	From string `json:"from"`         // Primer día pausado (YYYY-MM-DD)
+	To   string `json:"to,omitempty"` // Último día pausado; vacío: hasta que se reanude
+}
+
+// Contains indica si la pausa incluye la fecha dada (YYYY-MM-DD)
+func (p Pause) Contains(date string) bool {
+	return date >= p.From && (p.To == "" || date <= p.To)
+}
+
+// PausedOn indica si el hábito está pausado en la fecha dada (YYYY-MM-DD)
+func (h Habit) PausedOn(date string) bool {
+	return h.PauseOn(date) != nil
+}
+
+// PauseOn devuelve la pausa que incluye la fecha dada, o nil si no hay ninguna
+func (h Habit) PauseOn(date string) *Pause {
+	for i := range h.Pauses {
+		if h.Pauses[i].Contains(date) {
+			p := h.Pauses[i]
+			return &p
+		}
+	}
+	return nil
+}
+
+// PauseHabit pausa un hábito desde from hasta to (YYYY-MM-DD; to vacío: hasta
+// que se reanude). Si el hábito ya está pausado en from, se cambia el fin de
+// esa pausa.
+func (hm *HabitManager) PauseHabit(id int, from, to string) (*Habit, error) {
+	if _, err := time.Parse(DateFormat, from); err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", from, err)
+	}
+	if to != "" {
+		if _, err := time.Parse(DateFormat, to); err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", to, err)
+		}
+		if to < from {
+			return nil, fmt.Errorf("invalid pause: %s is before %s", to, from)
+		}
+	}
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := range hm.habits {
+		if hm.habits[i].ID != id {
+			continue
+		}
+		pauses := append([]Pause{}, hm.habits[i].Pauses...)
+		extended := false
+		for j := range pauses {
+			if pauses[j].Contains(from) {
+				pauses[j].To = to
+				extended = true
+				break
+			}
+		}
+		if !extended {
+			pauses = append(pauses, Pause{From: from, To: to})
+		}
+		hm.habits[i].Pauses = pauses
+		if err := hm.saveHabits(); err != nil {
+			return nil, err
+		}
+		h := hm.habits[i]
+		return &h, nil
+	}
+
+	return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
+
+// ResumeHabit reanuda un hábito pausado en date: la pausa termina el día
+// anterior, o se descarta si empezaba ese mismo día
+func (hm *HabitManager) ResumeHabit(id int, date string) (*Habit, error) {
+	day, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := range hm.habits {
+		if hm.habits[i].ID != id {
+			continue
+		}
+		var pauses []Pause
+		resumed := false
+		for _, p := range hm.habits[i].Pauses {
+			switch {
+			case !p.Contains(date):
+				pauses = append(pauses, p)
+			case p.From < date:
+				p.To = day.AddDate(0, 0, -1).Format(DateFormat)
+				pauses = append(pauses, p)
+				resumed = true
+			default:
+				resumed = true
+			}
+		}
+		if !resumed {
+			return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotPaused)
+		}
+		hm.habits[i].Pauses = pauses
+		if err := hm.saveHabits(); err != nil {
+			return nil, err
+		}
+		h := hm.habits[i]
+		return &h, nil
+	}
+
+	return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
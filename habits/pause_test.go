@@ -0,0 +1,77 @@
+package habits
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestPauseHabit prueba pausar, extender y reanudar un hábito
+func TestPauseHabit(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.habits = []Habit{{ID: 1, Name: "Correr", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)}}
+
+	if _, err := hm.PauseHabit(1, "2024-01-10", "2024-01-05"); err == nil {
+		t.Error("Expected an error for a pause that ends before it starts")
+	}
+	habit, err := hm.PauseHabit(1, "2024-01-10", "2024-01-12")
+	if err != nil {
+		t.Fatalf("PauseHabit failed: %v", err)
+	}
+	if !habit.DueOn("2024-01-09") || !habit.DueOn("2024-01-13") || habit.DueOn("2024-01-11") {
+		t.Errorf("Unexpected pause: %+v", habit.Pauses)
+	}
+
+	// Pausar durante una pausa cambia su fin
+	habit, _ = hm.PauseHabit(1, "2024-01-11", "")
+	if len(habit.Pauses) != 1 || !habit.PausedOn("2024-02-01") {
+		t.Fatalf("Expected the pause to be extended, got %+v", habit.Pauses)
+	}
+
+	habit, err = hm.ResumeHabit(1, "2024-01-15")
+	if err != nil {
+		t.Fatalf("ResumeHabit failed: %v", err)
+	}
+	if len(habit.Pauses) != 1 || habit.Pauses[0].To != "2024-01-14" || habit.PausedOn("2024-01-15") {
+		t.Errorf("Expected the pause to end the day before resuming, got %+v", habit.Pauses)
+	}
+	if _, err := hm.ResumeHabit(1, "2024-01-15"); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("Expected ErrNotPaused, got %v", err)
+	}
+
+	// Reanudar el mismo día en que empezó la pausa la descarta
+	hm.PauseHabit(1, "2024-01-20", "")
+	if habit, _ = hm.ResumeHabit(1, "2024-01-20"); len(habit.Pauses) != 1 {
+		t.Errorf("Expected the same-day pause to be removed, got %+v", habit.Pauses)
+	}
+	if _, err := hm.PauseHabit(2, "2024-01-20", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestStatsSkipPausedDays prueba que los días pausados no cuentan para el
+// porcentaje de cumplimiento ni cortan las rachas
+func TestStatsSkipPausedDays(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.habits = []Habit{{
+		ID:        1,
+		Name:      "Correr",
+		CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local),
+		Pauses:    []Pause{{From: "2024-01-03", To: "2024-01-05"}, {From: "2024-01-09"}},
+	}}
+	for _, date := range []string{"2024-01-01", "2024-01-02", "2024-01-04", "2024-01-06", "2024-01-07", "2024-01-08"} {
+		hm.dailyLogs = append(hm.dailyLogs, DailyLog{Date: date, HabitID: 1, Completed: true})
+	}
+
+	stats, err := hm.GetStats(1, "2024-01-01", "2024-01-10")
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	// 01-03, 01-05, 01-09 y 01-10 pausados; 01-04 se hizo igual
+	if stats.DaysPaused != 4 || stats.EligibleDays != 6 || stats.DaysCompleted != 6 || stats.CompletionRate != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.CurrentStreak != 6 || stats.BestStreak != 6 {
+		t.Errorf("Expected the pauses to keep the streak, got current %d and best %d", stats.CurrentStreak, stats.BestStreak)
+	}
+}
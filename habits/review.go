@@ -0,0 +1,63 @@
+package habits
+
+import "fmt"
+
+// MarkUnknown registra que no se sabe si los hábitos se hicieron en date (YYYY-MM-DD)
+// porque la revisión nocturna quedó sin respuesta. Los hábitos que ya se
+// marcaron como completados o se saltaron ese día no cambian.
+func (hm *HabitManager) MarkUnknown(date string, habitIDs []int) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	changed := false
+	for _, id := range habitIDs {
+		found := false
+		for i, log := range hm.dailyLogs {
+			if log.Date != date || log.HabitID != id {
+				continue
+			}
+			found = true
+			if log.Planned && !log.Completed && !log.Unknown {
+				hm.dailyLogs[i].Unknown = true
+				changed = true
+			}
+			break
+		}
+		if !found {
+			hm.dailyLogs = append(hm.dailyLogs, DailyLog{Date: date, HabitID: id, Unknown: true})
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return hm.saveDailyLogs()
+}
+
+// GetUnknown devuelve los logs de date que quedaron sin respuesta
+func (hm *HabitManager) GetUnknown(date string) []DailyLog {
+	var logs []DailyLog
+	for _, log := range hm.GetDailyPlans(date) {
+		if log.Unknown {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+// ResolveUnknown registra la respuesta tardía de un hábito que quedó sin
+// respuesta en date
+func (hm *HabitManager) ResolveUnknown(date string, habitID int, completed bool) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i, log := range hm.dailyLogs {
+		if log.Date == date && log.HabitID == habitID && log.Unknown {
+			hm.dailyLogs[i].Completed = completed
+			hm.dailyLogs[i].Unknown = false
+			return hm.saveDailyLogs()
+		}
+	}
+	return fmt.Errorf("no unknown log for habit %d on %s: %w", habitID, date, ErrNotFound)
+}
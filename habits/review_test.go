@@ -0,0 +1,51 @@
+package habits
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestUnknownDays prueba que los días sin respuesta quedan registrados como
+// desconocidos hasta que se responden
+func TestUnknownDays(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.habits = []Habit{
+		{ID: 1, Name: "Leer", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+		{ID: 2, Name: "Correr", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+		{ID: 3, Name: "Meditar", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+		{ID: 4, Name: "Nadar", CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)},
+	}
+	hm.dailyLogs = []DailyLog{
+		{Date: "2024-01-02", HabitID: 1, Planned: true},
+		{Date: "2024-01-02", HabitID: 3, Planned: true, Completed: true},
+		{Date: "2024-01-02", HabitID: 4}, // Saltado
+	}
+
+	if err := hm.MarkUnknown("2024-01-02", []int{1, 2, 3, 4}); err != nil {
+		t.Fatalf("MarkUnknown failed: %v", err)
+	}
+	unknown := hm.GetUnknown("2024-01-02")
+	if len(unknown) != 2 || unknown[0].HabitID != 1 || !unknown[0].Planned || unknown[1].HabitID != 2 {
+		t.Fatalf("Expected habits 1 and 2 to be unknown, got %+v", unknown)
+	}
+
+	stats, _ := hm.GetStats(1, "2024-01-01", "2024-01-03")
+	if stats.DaysUnknown != 1 || stats.DaysCompleted != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := hm.ResolveUnknown("2024-01-02", 1, true); err != nil {
+		t.Fatalf("ResolveUnknown failed: %v", err)
+	}
+	if err := hm.ResolveUnknown("2024-01-02", 1, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a resolved day to be final, got %v", err)
+	}
+	stats, _ = hm.GetStats(1, "2024-01-01", "2024-01-03")
+	if stats.DaysUnknown != 0 || stats.DaysCompleted != 1 {
+		t.Errorf("Unexpected stats after resolving: %+v", stats)
+	}
+	if unknown := hm.GetUnknown("2024-01-02"); len(unknown) != 1 || unknown[0].HabitID != 2 {
+		t.Errorf("Expected only habit 2 to be unknown, got %+v", unknown)
+	}
+}
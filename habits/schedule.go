@@ -0,0 +1,154 @@
+package habits
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReminderTimeFormat es el formato de la hora de recordatorio de un hábito
+const ReminderTimeFormat = "15:04"
+
+// weekdayNames son los nombres aceptados para cada día, en español e inglés
+var weekdayNames = map[string]time.Weekday{
+	"dom": time.Sunday, "sun": time.Sunday,
+	"lun": time.Monday, "mon": time.Monday,
+	"mar": time.Tuesday, "tue": time.Tuesday,
+	"mie": time.Wednesday, "mié": time.Wednesday, "wed": time.Wednesday,
+	"jue": time.Thursday, "thu": time.Thursday,
+	"vie": time.Friday, "fri": time.Friday,
+	"sab": time.Saturday, "sáb": time.Saturday, "sat": time.Saturday,
+}
+
+// ParseWeekdays interpreta una lista de días separada por comas ("lun,mie,vie").
+// "diario"/"daily" (o vacío) devuelve nil: todos los días. También acepta
+// "semana"/"weekdays" (lunes a viernes) y "finde"/"weekends".
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "diario", "daily":
+		return nil, nil
+	case "semana", "weekdays":
+		return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, nil
+	case "finde", "weekends":
+		return []time.Weekday{time.Sunday, time.Saturday}, nil
+	}
+
+	seen := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if len([]rune(part)) > 3 {
+			part = string([]rune(part)[:3])
+		}
+		day, ok := weekdayNames[part]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", part)
+		}
+		seen[day] = true
+	}
+
+	var days []time.Weekday
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if seen[day] {
+			days = append(days, day)
+		}
+	}
+	if len(days) == 7 {
+		return nil, nil
+	}
+	return days, nil
+}
+
+// DueOn indica si el hábito corresponde en la fecha dada (YYYY-MM-DD): desde su
+// creación, en los días de la semana configurados y fuera de sus pausas
+func (h Habit) DueOn(date string) bool {
+	d, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return false
+	}
+	if !h.CreatedAt.IsZero() && h.CreatedAt.Format(DateFormat) > date {
+		return false
+	}
+	if h.PausedOn(date) {
+		return false
+	}
+	if len(h.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range h.Weekdays {
+		if day == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseReminderTimes interpreta una o varias horas de recordatorio separadas
+// por comas ("07:00,13:00"). Devuelve las horas en formato HH:MM, ordenadas y
+// sin repetir; vacío devuelve nil.
+func ParseReminderTimes(s string) ([]string, error) {
+	seen := make(map[string]bool)
+	var times []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t, err := time.Parse(ReminderTimeFormat, part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder time %q (expected HH:MM)", part)
+		}
+		if formatted := t.Format(ReminderTimeFormat); !seen[formatted] {
+			seen[formatted] = true
+			times = append(times, formatted)
+		}
+	}
+	sort.Strings(times)
+	return times, nil
+}
+
+// ReminderTimes devuelve las horas de recordatorio del hábito
+func (h Habit) ReminderTimes() []string {
+	times, _ := ParseReminderTimes(h.ReminderTime)
+	return times
+}
+
+// ValidateSchedule verifica los días de la semana y las horas de recordatorio
+func ValidateSchedule(weekdays []time.Weekday, reminderTime string) error {
+	if _, err := ParseReminderTimes(reminderTime); err != nil {
+		return err
+	}
+	for _, day := range weekdays {
+		if day < time.Sunday || day > time.Saturday {
+			return fmt.Errorf("invalid weekday %d", day)
+		}
+	}
+	return nil
+}
+
+// SetSchedule cambia los días y las horas de recordatorio de un hábito
+func (hm *HabitManager) SetSchedule(id int, weekdays []time.Weekday, reminderTime string) (*Habit, error) {
+	if err := ValidateSchedule(weekdays, reminderTime); err != nil {
+		return nil, err
+	}
+	times, _ := ParseReminderTimes(reminderTime)
+	reminderTime = strings.Join(times, ",")
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for i := range hm.habits {
+		if hm.habits[i].ID == id {
+			hm.habits[i].Weekdays = weekdays
+			hm.habits[i].ReminderTime = reminderTime
+			if err := hm.saveHabits(); err != nil {
+				return nil, err
+			}
+			h := hm.habits[i]
+			return &h, nil
+		}
+	}
+
+	return nil, fmt.Errorf("habit with ID %d %w", id, ErrNotFound)
+}
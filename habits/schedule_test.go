@@ -0,0 +1,115 @@
+package habits
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseWeekdays prueba las formas aceptadas para indicar los días de un hábito
+func TestParseWeekdays(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	tests := []struct {
+		in   string
+		want []time.Weekday
+	}{
+		{"", nil},
+		{"daily", nil},
+		{"vie,lun, Miércoles", []time.Weekday{time.Monday, time.Wednesday, time.Friday}},
+		{"sat,sun", []time.Weekday{time.Sunday, time.Saturday}},
+		{"semana", weekdays},
+		{"lun,mar,mie,jue,vie,sab,dom", nil},
+	}
+	for _, tt := range tests {
+		days, err := ParseWeekdays(tt.in)
+		if err != nil {
+			t.Errorf("ParseWeekdays(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(days, tt.want) {
+			t.Errorf("ParseWeekdays(%q) = %v, want %v", tt.in, days, tt.want)
+		}
+	}
+
+	if _, err := ParseWeekdays("lun,xyz"); err == nil {
+		t.Error("Expected an error for an invalid weekday")
+	}
+}
+
+// TestDueOn prueba que un hábito corresponde desde su creación y en sus días
+func TestDueOn(t *testing.T) {
+	h := Habit{
+		CreatedAt: time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local), // lunes
+		Weekdays:  []time.Weekday{time.Monday, time.Friday},
+	}
+
+	for date, want := range map[string]bool{
+		"2024-03-01": false, // viernes, antes de la creación
+		"2024-03-04": true,
+		"2024-03-06": false,
+		"2024-03-08": true,
+	} {
+		if got := h.DueOn(date); got != want {
+			t.Errorf("DueOn(%s) = %v, want %v", date, got, want)
+		}
+	}
+}
+
+// TestStatsWeekdays prueba que los días en que el hábito no corresponde no
+// cuentan para el porcentaje de cumplimiento ni cortan las rachas
+func TestStatsWeekdays(t *testing.T) {
+	hm, _ := newTestManager(t)
+	hm.habits = []Habit{{
+		ID:        1,
+		Name:      "Correr",
+		CreatedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local), // lunes
+		Weekdays:  []time.Weekday{time.Monday, time.Wednesday, time.Friday},
+	}}
+	for _, date := range []string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-08", "2024-01-10", "2024-01-12"} {
+		hm.dailyLogs = append(hm.dailyLogs, DailyLog{Date: date, HabitID: 1, Completed: true})
+	}
+
+	stats, err := hm.GetStats(1, "2024-01-01", "2024-01-14")
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.EligibleDays != 6 || stats.DaysCompleted != 6 || stats.CompletionRate != 1 || stats.DaysPaused != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.CurrentStreak != 6 || stats.BestStreak != 6 {
+		t.Errorf("Expected the days off to keep the streak, got current %d and best %d", stats.CurrentStreak, stats.BestStreak)
+	}
+
+	// Faltar el lunes 15 sí corta la racha
+	stats, _ = hm.GetStats(1, "2024-01-01", "2024-01-16")
+	if stats.EligibleDays != 7 || stats.CurrentStreak != 0 || stats.BestStreak != 6 {
+		t.Errorf("Expected the missed day to break the streak, got %+v", stats)
+	}
+}
+
+// TestParseReminderTimes prueba las horas de recordatorio, una o varias
+func TestParseReminderTimes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"07:30", "07:30"},
+		{"13:00, 7:05,13:00", "07:05,13:00"},
+	}
+	for _, tt := range tests {
+		times, err := ParseReminderTimes(tt.in)
+		if err != nil {
+			t.Errorf("ParseReminderTimes(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got := strings.Join(times, ","); got != tt.want {
+			t.Errorf("ParseReminderTimes(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseReminderTimes("07:00,25:00"); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
@@ -0,0 +1,195 @@
+package habits
+
+import (
+	"time"
+)
+
+// HabitStats resume el progreso de un hábito en un rango de fechas
+type HabitStats struct {
+	HabitID        int     `json:"habit_id"`
+	HabitName      string  `json:"habit_name"`
+	From           string  `json:"from"`
+	To             string  `json:"to"`
+	EligibleDays   int     `json:"eligible_days"`
+	DaysPlanned    int     `json:"days_planned"`
+	DaysCompleted  int     `json:"days_completed"`
+	DaysUnknown    int     `json:"days_unknown"`
+	DaysPaused     int     `json:"days_paused"`
+	CompletionRate float64 `json:"completion_rate"`
+	CurrentStreak  int     `json:"current_streak"`
+	BestStreak     int     `json:"best_streak"`
+}
+
+// GetStats calcula las estadísticas de un hábito entre from y to (YYYY-MM-DD).
+// Las rachas se calculan sobre todo el historial, hasta to.
+func (hm *HabitManager) GetStats(habitID int, from, to string) (*HabitStats, error) {
+	habit, err := hm.GetHabit(habitID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDate, err := time.Parse(DateFormat, from)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := time.Parse(DateFormat, to)
+	if err != nil {
+		return nil, err
+	}
+
+	hm.mu.RLock()
+	completed := make(map[string]bool)
+	planned := make(map[string]bool)
+	unknown := make(map[string]bool)
+	for _, log := range hm.dailyLogs {
+		if log.HabitID != habitID {
+			continue
+		}
+		if log.Completed {
+			completed[log.Date] = true
+		}
+		if log.Planned {
+			planned[log.Date] = true
+		}
+		if log.Unknown {
+			unknown[log.Date] = true
+		}
+	}
+	hm.mu.RUnlock()
+
+	stats := &HabitStats{
+		HabitID:   habit.ID,
+		HabitName: habit.Name,
+		From:      from,
+		To:        to,
+	}
+
+	// Los días anteriores a la creación del hábito no cuentan
+	created, _ := time.Parse(DateFormat, habit.CreatedAt.Format(DateFormat))
+	start := fromDate
+	if created.After(start) {
+		start = created
+	}
+
+	// Los días en que el hábito no corresponde (pausas, días de la semana no
+	// elegidos) no cuentan, salvo que el hábito se haya hecho igual
+	skipped := habit.skippedDays(completed, start, toDate)
+	for d := start; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		date := d.Format(DateFormat)
+		if skipped[date] {
+			if habit.PausedOn(date) {
+				stats.DaysPaused++
+			}
+			continue
+		}
+		stats.EligibleDays++
+		if planned[date] {
+			stats.DaysPlanned++
+		}
+		if completed[date] {
+			stats.DaysCompleted++
+		}
+		if unknown[date] {
+			stats.DaysUnknown++
+		}
+	}
+
+	if stats.EligibleDays > 0 {
+		stats.CompletionRate = float64(stats.DaysCompleted) / float64(stats.EligibleDays)
+	}
+
+	stats.CurrentStreak, stats.BestStreak = streaks(completed, skipped, toDate)
+	return stats, nil
+}
+
+// skippedDays devuelve los días hasta end (inclusive) en que el hábito no
+// corresponde y no se hizo, desde from o desde el primer día completado o
+// pausado si es anterior
+func (h Habit) skippedDays(completed map[string]bool, from, end time.Time) map[string]bool {
+	start := from
+	first := func(date string) {
+		if d, err := time.Parse(DateFormat, date); err == nil && (start.IsZero() || d.Before(start)) {
+			start = d
+		}
+	}
+	for date := range completed {
+		first(date)
+	}
+	for _, p := range h.Pauses {
+		first(p.From)
+	}
+
+	days := make(map[string]bool)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(DateFormat)
+		if !completed[date] && !h.DueOn(date) {
+			days[date] = true
+		}
+	}
+	return days
+}
+
+// GetAllStats calcula las estadísticas de todos los hábitos
+func (hm *HabitManager) GetAllStats(from, to string) ([]HabitStats, error) {
+	all := []HabitStats{}
+	for _, habit := range hm.GetHabits() {
+		stats, err := hm.GetStats(habit.ID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, *stats)
+	}
+	return all, nil
+}
+
+// streaks calcula la racha actual (terminando en end, o el día anterior si end
+// aún no está completado) y la mejor racha hasta end. Los días salteados
+// (pausados o en que el hábito no corresponde) no cortan las rachas.
+func streaks(completed, skipped map[string]bool, end time.Time) (current, best int) {
+	// Empezar desde el primer día completado
+	var start time.Time
+	for date := range completed {
+		d, err := time.Parse(DateFormat, date)
+		if err != nil {
+			continue
+		}
+		if start.IsZero() || d.Before(start) {
+			start = d
+		}
+	}
+	if start.IsZero() {
+		return 0, 0
+	}
+
+	run := 0
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(DateFormat)
+		switch {
+		case completed[date]:
+			run++
+			if run > best {
+				best = run
+			}
+		case skipped[date]:
+			// Un día salteado no suma ni corta la racha
+		default:
+			run = 0
+		}
+	}
+
+	day := end
+	if !completed[day.Format(DateFormat)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for !day.Before(start) {
+		date := day.Format(DateFormat)
+		if completed[date] {
+			current++
+		} else if !skipped[date] {
+			break
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return current, best
+}
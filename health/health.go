@@ -0,0 +1,99 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Check es una verificación de disponibilidad con nombre
+type Check struct {
+	Name string
+	Fn   func() error
+}
+
+// CheckResult es el resultado de una verificación
+type CheckResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	TookMS int64  `json:"took_ms"`
+}
+
+// Status es la respuesta de /readyz
+type Status struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// LiveHandler responde a /healthz: el proceso está vivo y atendiendo HTTP
+func LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+// ReadyHandler responde a /readyz ejecutando todas las verificaciones.
+// Devuelve 503 si alguna falla.
+func ReadyHandler(checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := Run(checks)
+
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// Run ejecuta las verificaciones en paralelo
+func Run(checks []Check) Status {
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Fn()
+			results[i] = CheckResult{
+				Name:   check.Name,
+				OK:     err == nil,
+				TookMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	status := Status{Ready: true, Checks: results}
+	for _, result := range results {
+		if !result.OK {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+// DirWritable verifica que se pueda escribir en el directorio dado
+func DirWritable(dir string) func() error {
+	return func() error {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return fmt.Errorf("storage not writable: %w", err)
+		}
+		name := f.Name()
+		f.Close()
+		return os.Remove(filepath.Clean(name))
+	}
+}
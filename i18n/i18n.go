@@ -0,0 +1,191 @@
+// Package i18n contiene los catálogos de mensajes del bot y elige el idioma de
+// cada usuario. Los catálogos son archivos JSON embebidos en el binario, uno por
+// idioma; cada mensaje es un texto de fmt o un objeto con formas de plural.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// DefaultLanguage es el idioma de los usuarios sin preferencia ni idioma conocido
+const DefaultLanguage = "es"
+
+// message es un mensaje del catálogo. Los mensajes sin plural solo tienen Other.
+type message struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		m.Other = s
+		return nil
+	}
+	type forms message
+	return json.Unmarshal(data, (*forms)(m))
+}
+
+// pluralRules elige la forma de plural según la cantidad, por idioma
+var pluralRules = map[string]func(n int) string{
+	"es": oneOther,
+	"en": oneOther,
+}
+
+func oneOther(n int) string {
+	if n == 1 || n == -1 {
+		return "one"
+	}
+	return "other"
+}
+
+// Localizer traduce los mensajes a un idioma
+type Localizer struct {
+	lang     string
+	messages map[string]message
+}
+
+var (
+	catalogs       = make(map[string]*Localizer)
+	defaultCatalog atomic.Pointer[Localizer]
+)
+
+func init() {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		data, err := localeFS.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		l := &Localizer{lang: lang}
+		if err := json.Unmarshal(data, &l.messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", f.Name(), err))
+		}
+		catalogs[lang] = l
+	}
+	defaultCatalog.Store(catalogs[DefaultLanguage])
+}
+
+// Languages devuelve los idiomas disponibles
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Match devuelve el idioma disponible que corresponde a un código de idioma
+// como los de Telegram ("en", "en-US", "es-419")
+func Match(code string) (string, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i != -1 {
+		code = code[:i]
+	}
+	_, ok := catalogs[code]
+	return code, ok
+}
+
+// SetDefault cambia el idioma por defecto
+func SetDefault(lang string) error {
+	l, ok := catalogs[lang]
+	if !ok {
+		return fmt.Errorf("unsupported language %q (available: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	defaultCatalog.Store(l)
+	return nil
+}
+
+// Get devuelve el localizador de un idioma, o el del idioma por defecto si no está disponible
+func Get(lang string) *Localizer {
+	if l, ok := catalogs[lang]; ok {
+		return l
+	}
+	return defaultCatalog.Load()
+}
+
+// Lang devuelve el código del idioma
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
+// T devuelve el mensaje formateado con args
+func (l *Localizer) T(key string, args ...any) string {
+	return l.format(key, "other", args)
+}
+
+// N devuelve el mensaje en la forma de plural que corresponde a n. n es el
+// primer argumento del formato, seguido de args.
+func (l *Localizer) N(key string, n int, args ...any) string {
+	rule := pluralRules[l.lang]
+	if rule == nil {
+		rule = oneOther
+	}
+	return l.format(key, rule(n), append([]any{n}, args...))
+}
+
+// Weekdays muestra los días de un hábito ("lun,mie"), o que corresponde todos
+// los días si la lista está vacía
+func (l *Localizer) Weekdays(days []time.Weekday) string {
+	if len(days) == 0 {
+		return l.T("weekdays.daily")
+	}
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = l.T(fmt.Sprintf("weekday.%d", day))
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *Localizer) format(key, form string, args []any) string {
+	m, ok := l.messages[key]
+	if !ok {
+		// Un mensaje faltante se muestra en el idioma por defecto antes que como clave
+		if def := defaultCatalog.Load(); def != l {
+			return def.format(key, form, args)
+		}
+		return key
+	}
+
+	text := m.Other
+	if form == "one" && m.One != "" {
+		text = m.One
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+type contextKey struct{}
+
+// WithLocalizer devuelve un contexto que lleva el localizador dado
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext devuelve el localizador del contexto, o el del idioma por defecto
+func FromContext(ctx context.Context) *Localizer {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Localizer); ok {
+			return l
+		}
+	}
+	return defaultCatalog.Load()
+}
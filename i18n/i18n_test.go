@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// TestCatalogsComplete prueba que todos los idiomas tienen los mismos mensajes,
+// con las mismas formas de plural y los mismos argumentos de formato
+func TestCatalogsComplete(t *testing.T) {
+	def := catalogs[DefaultLanguage]
+	if def == nil {
+		t.Fatalf("Missing default catalog %q", DefaultLanguage)
+	}
+
+	for _, lang := range Languages() {
+		l := catalogs[lang]
+		if _, ok := pluralRules[lang]; !ok {
+			t.Errorf("%s: missing plural rule", lang)
+		}
+		for key, want := range def.messages {
+			got, ok := l.messages[key]
+			if !ok {
+				t.Errorf("%s: missing message %q", lang, key)
+				continue
+			}
+			if (want.One == "") != (got.One == "") {
+				t.Errorf("%s: message %q has different plural forms", lang, key)
+			}
+			if !slices.Equal(verb.FindAllString(want.Other, -1), verb.FindAllString(got.Other, -1)) ||
+				!slices.Equal(verb.FindAllString(want.One, -1), verb.FindAllString(got.One, -1)) {
+				t.Errorf("%s: message %q has different format verbs", lang, key)
+			}
+		}
+		for key := range l.messages {
+			if _, ok := def.messages[key]; !ok {
+				t.Errorf("%s: message %q is not in the default catalog", lang, key)
+			}
+		}
+	}
+}
+
+// TestLocalizer prueba la elección del idioma, el plural y el respaldo al idioma por defecto
+func TestLocalizer(t *testing.T) {
+	for code, want := range map[string]string{"en": "en", "en-US": "en", "ES_419": "es"} {
+		if lang, ok := Match(code); !ok || lang != want {
+			t.Errorf("Match(%q) = %q, %v; expected %q", code, lang, ok, want)
+		}
+	}
+	if _, ok := Match("fr"); ok {
+		t.Error("Expected fr not to match")
+	}
+	if Get("fr").Lang() != DefaultLanguage {
+		t.Errorf("Expected unknown language to fall back to %q", DefaultLanguage)
+	}
+
+	en := Get("en")
+	if got := en.N("export.habits", 1); got != "1 habit" {
+		t.Errorf("Unexpected singular: %q", got)
+	}
+	if got := en.N("export.habits", 3); got != "3 habits" {
+		t.Errorf("Unexpected plural: %q", got)
+	}
+	if got := Get("es").N("export.rows", 0); got != "0 filas" {
+		t.Errorf("Unexpected plural for zero: %q", got)
+	}
+	if got := en.T("addhabit.added", 7, "Read"); got != "✅ Habit added!\n\nID: 7\nName: Read" {
+		t.Errorf("Unexpected formatted message: %q", got)
+	}
+
+	if got := Get("es").Weekdays([]time.Weekday{time.Monday, time.Friday}); got != "lun,vie" {
+		t.Errorf("Unexpected weekdays: %q", got)
+	}
+	if got := en.Weekdays(nil); got != "daily" {
+		t.Errorf("Unexpected daily weekdays: %q", got)
+	}
+
+	// Los mensajes faltantes caen en el idioma por defecto, y luego en la clave
+	catalogs[DefaultLanguage].messages["test.only_es"] = message{Other: "solo en español"}
+	defer delete(catalogs[DefaultLanguage].messages, "test.only_es")
+	if got := en.T("test.only_es"); got != "solo en español" {
+		t.Errorf("Expected fallback to the default language, got %q", got)
+	}
+	if got := en.T("test.missing"); got != "test.missing" {
+		t.Errorf("Expected missing key, got %q", got)
+	}
+}
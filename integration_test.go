@@ -48,12 +48,15 @@ func TestEndToEndNotification(t *testing.T) {
 	t.Log("✅ Hábitos de prueba creados")
 
 	// Inicializar el bot
-	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager)
+	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager, testDataDir+"/outbox.json")
 	if err != nil {
 		t.Fatalf("Failed to create bot: %v", err)
 	}
 	t.Log("✅ Bot inicializado")
 
+	telegramBot.StartOutbox()
+	defer telegramBot.StopOutbox()
+
 	// Configurar el chat ID desde la variable de entorno o usar uno de prueba
 	chatID := config.AppConfig.TelegramChatID
 	if chatID == "" {
@@ -115,6 +118,10 @@ func TestEndToEndNotification(t *testing.T) {
 	// Esperar más tiempo del programado para asegurar que se ejecute
 	time.Sleep(8 * time.Second)
 
+	if err := telegramBot.FlushOutbox(10 * time.Second); err != nil {
+		t.Errorf("❌ %v", err)
+	}
+
 	// Verificar que el callback se ejecutó
 	if !callbackExecuted {
 		t.Error("❌ El callback no se ejecutó dentro del tiempo esperado")
@@ -159,12 +166,15 @@ func TestEndToEndWithManualTrigger(t *testing.T) {
 	t.Log("✅ Hábitos de prueba creados")
 
 	// Inicializar el bot
-	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager)
+	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager, testDataDir+"/outbox.json")
 	if err != nil {
 		t.Fatalf("Failed to create bot: %v", err)
 	}
 	t.Log("✅ Bot inicializado")
 
+	telegramBot.StartOutbox()
+	defer telegramBot.StopOutbox()
+
 	// Configurar el chat ID desde la variable de entorno
 	chatID := config.AppConfig.TelegramChatID
 	if chatID == "" {
@@ -198,6 +208,8 @@ func TestEndToEndWithManualTrigger(t *testing.T) {
 	t.Log("✅ Notificación enviada exitosamente!")
 	t.Log("📱 Revisa tu Telegram, deberías haber recibido la notificación")
 
-	// Dar tiempo para que el mensaje se envíe
-	time.Sleep(1 * time.Second)
+	// Esperar a que la cola entregue los mensajes
+	if err := telegramBot.FlushOutbox(10 * time.Second); err != nil {
+		t.Fatalf("❌ %v", err)
+	}
 }
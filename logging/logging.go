@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+const redacted = "[redacted]"
+
+// debugMode habilita el registro de datos personales (nombres, texto de mensajes)
+var debugMode atomic.Bool
+
+type contextKey struct{}
+
+// Setup configura el logger global de slog.
+// level: debug, info, warn o error. format: text o json.
+// debug habilita el registro de datos personales sin redactar.
+func Setup(level, format string, debug bool) error {
+	handler, err := NewHandler(os.Stderr, level, format)
+	if err != nil {
+		return err
+	}
+
+	debugMode.Store(debug)
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// NewHandler crea un handler de slog con el nivel y formato indicados
+func NewHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (expected text or json)", format)
+	}
+}
+
+// Debug indica si está habilitado el registro de datos personales
+func Debug() bool {
+	return debugMode.Load()
+}
+
+// SetDebug habilita o deshabilita el registro de datos personales
+func SetDebug(debug bool) {
+	debugMode.Store(debug)
+}
+
+// Redact devuelve s si el modo debug está habilitado, o un marcador en caso contrario.
+// Usar para nombres, usernames y texto escrito por el usuario.
+func Redact(s string) string {
+	if debugMode.Load() || s == "" {
+		return s
+	}
+	return redacted
+}
+
+// WithLogger devuelve un contexto que lleva el logger dado
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext devuelve el logger del contexto, o el logger global si no hay uno
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// Fatal registra un error y termina el proceso
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+// TestRedact prueba que los datos personales se redactan salvo en modo debug
+func TestRedact(t *testing.T) {
+	SetDebug(false)
+	if got := Redact("Juan"); got != redacted {
+		t.Errorf("Expected %q, got %q", redacted, got)
+	}
+	if got := Redact(""); got != "" {
+		t.Errorf("Expected empty string to stay empty, got %q", got)
+	}
+
+	SetDebug(true)
+	defer SetDebug(false)
+	if got := Redact("Juan"); got != "Juan" {
+		t.Errorf("Expected value in debug mode, got %q", got)
+	}
+}
+
+// TestNewHandler prueba la selección de nivel y formato
+func TestNewHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, "warn", "json")
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	logger := slog.New(handler)
+	logger.Info("ignored")
+	logger.Warn("kept", "chat_id", 42)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "kept" || entry["chat_id"] != float64(42) {
+		t.Errorf("Unexpected log entry: %v", entry)
+	}
+
+	if _, err := NewHandler(&buf, "verbose", "text"); err == nil {
+		t.Error("Expected error for invalid level")
+	}
+	if _, err := NewHandler(&buf, "info", "xml"); err == nil {
+		t.Error("Expected error for invalid format")
+	}
+}
+
+// TestFromContext prueba que el logger viaja en el contexto
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("Expected default logger for empty context")
+	}
+
+	logger := slog.Default().With("update_id", 1)
+	ctx := WithLogger(context.Background(), logger)
+	if FromContext(ctx) != logger {
+		t.Error("Expected logger from context")
+	}
+}
@@ -2,113 +2,335 @@ package main
 
 import (
 	"fmt"
+	"habittracker/api"
+	"habittracker/auth"
+	"habittracker/backup"
 	"habittracker/bot"
+	"habittracker/calendar"
 	"habittracker/config"
 	"habittracker/habits"
+	"habittracker/health"
+	"habittracker/i18n"
+	"habittracker/logging"
+	"habittracker/metrics"
+	"habittracker/notes"
 	"habittracker/scheduler"
-	"log"
+	"habittracker/settings"
+	"habittracker/storage"
+	"habittracker/web"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
 	// Cargar configuración
 	if err := config.LoadConfig(); err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		logging.Fatal("Error loading config", "error", err)
 	}
 
-	log.Println("Configuration loaded successfully")
+	// Configurar logging estructurado
+	if err := logging.Setup(config.AppConfig.LogLevel, config.AppConfig.LogFormat, config.AppConfig.LogDebug); err != nil {
+		logging.Fatal("Error configuring logging", "error", err)
+	}
+
+	slog.Info("Configuration loaded successfully")
+	if config.AppConfig.LogDebug {
+		slog.Warn("Debug logging enabled: personal data will not be redacted")
+	}
 
 	// Crear directorios de datos si no existen
 	if err := os.MkdirAll("data", 0755); err != nil {
-		log.Fatalf("Error creating data directory: %v", err)
+		logging.Fatal("Error creating data directory", "error", err)
+	}
+
+	// Cifrado de los archivos de datos (opcional)
+	keyring, err := storage.KeyringFromEnv()
+	if err != nil {
+		logging.Fatal("Error loading encryption key", "error", err)
+	}
+	if keyring != nil {
+		storage.SetKeyring(keyring)
+		slog.Info("Encryption at rest enabled")
 	}
 
 	// Inicializar el gestor de hábitos
-	habitManager := habits.NewHabitManager("data/habits.json", "data/responses.json", "data/daily_logs.json")
-	log.Println("Habit manager initialized")
+	habitManager, err := habits.OpenHabitManager("data/habits.json", "data/responses.json", "data/daily_logs.json")
+	if err != nil {
+		logging.Fatal("Error loading data files (run 'habitctl validate')", "error", err)
+	}
+	slog.Info("Habit manager initialized")
+
+	// Aplicar migraciones de esquema pendientes
+	applied, err := habitManager.Migrate()
+	for _, m := range applied {
+		slog.Info("Applied data migration", "version", m.Version, "description", m.Description)
+	}
+	if err != nil {
+		logging.Fatal("Error migrating data", "error", err)
+	}
 
 	// Inicializar el bot
-	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager)
+	telegramBot, err := bot.NewBot(config.AppConfig.TelegramBotToken, habitManager, "data/outbox.json")
+	if err != nil {
+		logging.Fatal("Error creating bot", "error", err)
+	}
+
+	// Idioma de los mensajes del bot, por usuario
+	if err := i18n.SetDefault(config.AppConfig.DefaultLanguage); err != nil {
+		logging.Fatal("Invalid DEFAULT_LANGUAGE", "error", err)
+	}
+	userSettings, err := settings.NewStore("data/settings.json")
+	if err != nil {
+		logging.Fatal("Error loading user settings", "error", err)
+	}
+	telegramBot.SetSettings(userSettings)
+
+	// Plantillas de mensajes: se validan al iniciar para no fallar en el saludo
+	templates, err := bot.LoadTemplates(config.AppConfig.TemplatesDir)
+	if err != nil {
+		logging.Fatal("Invalid message templates", "dir", config.AppConfig.TemplatesDir, "error", err)
+	}
+	telegramBot.SetTemplates(templates)
+
+	conversations, err := bot.NewConversations("data/conversations.json", config.AppConfig.ConversationTimeout)
+	if err != nil {
+		logging.Fatal("Error loading conversations", "error", err)
+	}
+	telegramBot.SetConversations(conversations)
+
+	reviews, err := bot.NewReviews("data/reviews.json")
 	if err != nil {
-		log.Fatalf("Error creating bot: %v", err)
+		logging.Fatal("Error loading review state", "error", err)
 	}
+	telegramBot.SetReviews(reviews)
+
+	// Control de acceso: con usuarios o administradores configurados el bot es privado
+	var users *auth.UserStore
+	if config.AppConfig.AccessControl() {
+		users, err = auth.NewUserStore("data/users.json", config.AppConfig.AllowedUserIDs, config.AppConfig.AdminUserIDs)
+		if err != nil {
+			logging.Fatal("Error loading users", "error", err)
+		}
+		telegramBot.SetAccess(users)
+		slog.Info("Access control enabled", "allowed_users", len(config.AppConfig.AllowedUserIDs), "admins", len(config.AppConfig.AdminUserIDs))
+	} else {
+		slog.Warn("Access control disabled: anyone who finds the bot can use it (set ALLOWED_USER_IDS or ADMIN_USER_IDS)")
+	}
+
+	// Tokens de la API REST (se emiten con /apitoken)
+	tokenStore, err := auth.NewTokenStore("data/api_tokens.json")
+	if err != nil {
+		logging.Fatal("Error loading API tokens", "error", err)
+	}
+	telegramBot.SetTokenStore(tokenStore)
+
+	// Dashboard web (acceso con enlace de un solo uso enviado por /dashboard)
+	sessions := auth.NewSessionStore()
+	telegramBot.SetDashboard(sessions, config.AppConfig.PublicURL)
+
+	// Feed de calendario (URL secreta por usuario enviada por /calendar)
+	calendarTokens, err := auth.NewTokenStore("data/calendar_tokens.json")
+	if err != nil {
+		logging.Fatal("Error loading calendar tokens", "error", err)
+	}
+	telegramBot.SetCalendar(calendarTokens, config.AppConfig.PublicURL)
+
+	// Iniciar la cola de mensajes salientes
+	telegramBot.StartOutbox()
 
 	// Inicializar el scheduler
 	sched, err := scheduler.NewScheduler(config.AppConfig.Timezone)
 	if err != nil {
-		log.Fatalf("Error creating scheduler: %v", err)
+		logging.Fatal("Error creating scheduler", "error", err)
 	}
 
+	dashboard, err := web.NewDashboard(habitManager, sessions, sched.Location())
+	if err != nil {
+		logging.Fatal("Error creating dashboard", "error", err)
+	}
+	dashboard.SetUsers(users)
+
+	feed := calendar.NewFeed(habitManager, calendarTokens, sched.Location())
+	feed.SetUsers(users)
+	feed.SetSettings(userSettings)
+
+	// Los días del bot (registros, /today, pausas, recordatorios y horarios de
+	// silencio) se cuentan en la zona horaria del scheduler
+	telegramBot.SetLocation(sched.Location())
+	telegramBot.SetQuietHours(config.AppConfig.QuietHoursMode)
+
 	// Programar saludo matutino (Planificación)
-	if err := sched.ScheduleDailyReminder(config.AppConfig.MorningTime, func() {
+	if err := sched.ScheduleNamedReminder("morning_greeting", config.AppConfig.MorningTime, func() {
 		if err := telegramBot.SendMorningGreeting(); err != nil {
-			log.Printf("Error sending morning greeting: %v", err)
+			slog.Error("Error sending morning greeting", "error", err)
 		}
 	}); err != nil {
-		log.Fatalf("Error scheduling morning greeting: %v", err)
+		logging.Fatal("Error scheduling morning greeting", "error", err)
 	}
 
 	// Programar revisión nocturna (Verificación)
-	if err := sched.ScheduleDailyReminder(config.AppConfig.EveningTime, func() {
+	if err := sched.ScheduleNamedReminder("evening_review", config.AppConfig.EveningTime, func() {
 		if err := telegramBot.SendEveningReview(); err != nil {
-			log.Printf("Error sending evening review: %v", err)
+			slog.Error("Error sending evening review", "error", err)
 		}
 	}); err != nil {
-		log.Fatalf("Error scheduling evening review: %v", err)
+		logging.Fatal("Error scheduling evening review", "error", err)
+	}
+
+	// Volver a preguntar lo que quedó sin responder antes de que termine el día
+	if config.AppConfig.ReviewReminder != "off" {
+		if err := sched.ScheduleNamedReminder("review_reminder", config.AppConfig.ReviewReminder, func() {
+			if err := telegramBot.SendReviewReminder(); err != nil {
+				slog.Error("Error sending review reminder", "error", err)
+			}
+		}); err != nil {
+			logging.Fatal("Error scheduling review reminder", "error", err)
+		}
+	}
+
+	// Notas diarias en Markdown (Obsidian, Logseq)
+	if config.AppConfig.DailyNotesDir != "" {
+		syncer, err := notes.NewSyncer(habitManager, config.AppConfig.DailyNotesDir, config.AppConfig.DailyNotesFilename)
+		if err != nil {
+			logging.Fatal("Error configuring daily notes", "error", err)
+		}
+		if err := sched.ScheduleInterval("daily_notes", config.AppConfig.DailyNotesInterval, func() {
+			updated, err := syncer.Sync(time.Now().In(sched.Location()), config.AppConfig.DailyNotesDays, config.AppConfig.DailyNotesReadBack)
+			if err != nil {
+				slog.Error("Error syncing daily notes", "error", err)
+				return
+			}
+			if updated > 0 {
+				slog.Info("Daily notes synced", "logs_updated", updated)
+			}
+		}); err != nil {
+			logging.Fatal("Error scheduling daily notes", "error", err)
+		}
+	}
+
+	// Backups del directorio de datos
+	backups := backup.NewManager("data", config.AppConfig.BackupDir, backup.Retention{
+		Daily:   config.AppConfig.BackupKeepDaily,
+		Weekly:  config.AppConfig.BackupKeepWeekly,
+		Monthly: config.AppConfig.BackupKeepMonthly,
+	})
+	telegramBot.SetBackups(backups, config.AppConfig.AdminChatID)
+	if config.AppConfig.BackupTime != "off" {
+		if err := sched.ScheduleNamedReminder("backup", config.AppConfig.BackupTime, func() {
+			path, removed, err := backups.Run(time.Now().In(sched.Location()))
+			if err != nil {
+				slog.Error("Error creating backup", "error", err)
+				return
+			}
+			slog.Info("Backup created", "path", path, "pruned", len(removed))
+		}); err != nil {
+			logging.Fatal("Error scheduling backup", "error", err)
+		}
+	}
+
+	// Menú de comandos de Telegram, con los comandos de administración solo para los administradores
+	if err := telegramBot.RegisterCommands(); err != nil {
+		slog.Warn("Error registering bot commands", "error", err)
+	}
+
+	// Recordatorios por hábito: se revisan cada minuto, así los cambios de horario se aplican sin reiniciar
+	reminders, err := bot.NewReminders("data/reminders.json", config.AppConfig.ReminderNudge)
+	if err != nil {
+		logging.Fatal("Error loading reminders", "error", err)
+	}
+	telegramBot.SetReminders(reminders)
+	if err := sched.ScheduleInterval("habit_reminders", time.Minute, func() {
+		telegramBot.CheckReminders(time.Now().In(sched.Location()))
+	}); err != nil {
+		logging.Fatal("Error scheduling habit reminders", "error", err)
 	}
 
 	// Iniciar el scheduler
 	sched.Start()
 
+	// Gauges expuestos en /metrics
+	metrics.RegisterGauge("habittracker_habits", "Cantidad de hábitos activos (no archivados).", func() float64 {
+		return float64(len(habitManager.GetActiveHabits()))
+	})
+	metrics.RegisterGauge("habittracker_users", "Cantidad de usuarios conocidos por el bot.", func() float64 {
+		return float64(telegramBot.UserCount())
+	})
+	metrics.RegisterGauge("habittracker_outbox_pending", "Mensajes salientes pendientes de entrega.", func() float64 {
+		return float64(telegramBot.PendingMessages())
+	})
+
+	// Servidor HTTP: salud, métricas, API, dashboard y calendario, en los dos modos
+	http.HandleFunc("/healthz", health.LiveHandler())
+	http.HandleFunc("/readyz", health.ReadyHandler(
+		health.Check{Name: "storage", Fn: health.DirWritable("data")},
+		health.Check{Name: "scheduler", Fn: sched.CheckRunning},
+		health.Check{Name: "telegram", Fn: telegramBot.Ping},
+	))
+	http.HandleFunc("/metrics", metrics.RequireToken(config.AppConfig.MetricsToken, metrics.Default.Handler()))
+	if config.AppConfig.MetricsToken == "" {
+		slog.Warn("/metrics has no authentication: set METRICS_TOKEN or keep the port private")
+	}
+	apiServer := api.NewServer(habitManager, tokenStore, sched.Location())
+	apiServer.SetUsers(users)
+	http.Handle("/api/", apiServer.Handler())
+	http.Handle("/dashboard", dashboard.Handler())
+	http.Handle("/dashboard/", dashboard.Handler())
+	http.Handle("/calendar/", feed.Handler())
+
+	addr := fmt.Sprintf(":%s", config.AppConfig.Port)
+	mode := "polling"
+
 	// Configurar modo de operación: webhook o polling
 	if config.AppConfig.WebhookURL != "" {
 		// Modo webhook
-		log.Println("🌐 Starting in WEBHOOK mode")
+		mode = "webhook"
+		slog.Info("Starting in webhook mode")
 
 		// Configurar el webhook en Telegram
-		if err := telegramBot.SetWebhook(config.AppConfig.WebhookURL); err != nil {
-			log.Fatalf("Error setting webhook: %v", err)
+		if err := telegramBot.SetWebhook(bot.WebhookOptions{
+			URL:         config.AppConfig.WebhookURL,
+			SecretToken: config.AppConfig.WebhookSecret,
+		}); err != nil {
+			logging.Fatal("Error setting webhook", "error", err)
 		}
-
-		// Configurar el servidor HTTP
 		http.HandleFunc("/", telegramBot.GetWebhookHandler())
-
-		addr := fmt.Sprintf(":%s", config.AppConfig.Port)
-		log.Printf("✅ Habit Tracker Bot is running in WEBHOOK mode!")
-		log.Printf("📡 Listening on %s", addr)
-		log.Printf("🔗 Webhook URL: %s", config.AppConfig.WebhookURL)
-		log.Printf("📅 Morning greeting scheduled at %s (%s)", config.AppConfig.MorningTime, config.AppConfig.Timezone)
-		log.Printf("📅 Evening review scheduled at %s (%s)", config.AppConfig.EveningTime, config.AppConfig.Timezone)
-
-		// Iniciar servidor HTTP en una goroutine
-		go func() {
-			if err := http.ListenAndServe(addr, nil); err != nil {
-				log.Fatalf("Error starting HTTP server: %v", err)
-			}
-		}()
 	} else {
 		// Modo long polling (fallback)
-		log.Println("📞 Starting in POLLING mode")
+		slog.Info("Starting in polling mode")
 
 		// Iniciar el bot en una goroutine
 		go telegramBot.Start()
-
-		log.Println("✅ Habit Tracker Bot is running in POLLING mode!")
-		log.Printf("📅 Morning greeting scheduled at %s (%s)", config.AppConfig.MorningTime, config.AppConfig.Timezone)
-		log.Printf("📅 Evening review scheduled at %s (%s)", config.AppConfig.EveningTime, config.AppConfig.Timezone)
 	}
 
-	log.Println("Press Ctrl+C to stop")
+	slog.Info("Habit Tracker Bot is running",
+		"mode", mode,
+		"addr", addr,
+		"webhook_url", config.AppConfig.WebhookURL,
+		"morning_time", config.AppConfig.MorningTime,
+		"evening_time", config.AppConfig.EveningTime,
+		"timezone", config.AppConfig.Timezone,
+	)
+
+	// Iniciar servidor HTTP en una goroutine
+	go func() {
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			logging.Fatal("Error starting HTTP server", "error", err)
+		}
+	}()
+
+	slog.Info("Press Ctrl+C to stop")
 
 	// Esperar señal de interrupción
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
-	log.Println("\n🛑 Shutting down gracefully...")
+	slog.Info("Shutting down gracefully")
 	sched.Stop()
-	log.Println("Goodbye!")
+	telegramBot.StopOutbox()
+	slog.Info("Goodbye")
 }
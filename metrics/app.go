@@ -0,0 +1,63 @@
+package metrics
+
+// Default es el registro global que expone /metrics
+var Default = NewRegistry()
+
+// Métricas de la aplicación
+var (
+	UpdatesTotal = Default.NewCounterVec(
+		"habittracker_updates_total",
+		"Updates de Telegram recibidos, por tipo.",
+		"type",
+	)
+
+	UpdateDuration = Default.NewHistogramVec(
+		"habittracker_update_duration_seconds",
+		"Tiempo de procesamiento de cada update, por tipo.",
+		"type",
+		DefaultBuckets,
+	)
+
+	CommandsTotal = Default.NewCounterVec(
+		"habittracker_commands_total",
+		"Comandos procesados, por comando.",
+		"command",
+	)
+
+	CallbacksRejectedTotal = Default.NewCounterVec(
+		"habittracker_callbacks_rejected_total",
+		"Botones rechazados por datos inválidos, por motivo.",
+		"reason",
+	)
+
+	SendFailuresTotal = Default.NewCounterVec(
+		"habittracker_send_failures_total",
+		"Errores al enviar a Telegram, por motivo.",
+		"reason",
+	)
+
+	SendDuration = Default.NewHistogramVec(
+		"habittracker_send_duration_seconds",
+		"Latencia de las llamadas de envío a Telegram, por resultado.",
+		"result",
+		DefaultBuckets,
+	)
+
+	JobRunsTotal = Default.NewCounterVec(
+		"habittracker_scheduled_job_runs_total",
+		"Ejecuciones de tareas programadas, por tarea.",
+		"job",
+	)
+
+	JobDuration = Default.NewHistogramVec(
+		"habittracker_scheduled_job_duration_seconds",
+		"Duración de las tareas programadas, por tarea.",
+		"job",
+		DefaultBuckets,
+	)
+)
+
+// RegisterGauge registra un gauge calculado en el registro global
+func RegisterGauge(name, help string, fn func() float64) {
+	Default.NewGaugeFunc(name, help, fn)
+}
@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Buckets por defecto para latencias, en segundos
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector es cualquier métrica que sabe escribirse en formato de texto de Prometheus
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry agrupa las métricas expuestas en /metrics
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry crea un registro vacío
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Handler devuelve el handler HTTP que expone las métricas
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	}
+}
+
+// RequireToken exige token en el header Authorization (Bearer) antes de
+// llamar a next. Con token vacío no se exige nada.
+func RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	if token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, req)
+	}
+}
+
+// Write escribe todas las métricas en formato de texto de Prometheus
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// CounterVec es un contador con una etiqueta
+type CounterVec struct {
+	metricName string
+	help       string
+	label      string
+	mu         sync.Mutex
+	values     map[string]float64
+}
+
+// NewCounterVec crea y registra un contador con una etiqueta
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{metricName: name, help: help, label: label, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc incrementa en uno el contador para el valor de etiqueta dado
+func (c *CounterVec) Inc(labelValue string) {
+	c.Add(labelValue, 1)
+}
+
+// Add suma delta al contador para el valor de etiqueta dado
+func (c *CounterVec) Add(labelValue string, delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelValue] += delta
+}
+
+// Value devuelve el valor actual para una etiqueta
+func (c *CounterVec) Value(labelValue string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labelValue]
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.metricName, c.help, "counter")
+	for _, lv := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s{%s=%q} %s\n", c.metricName, c.label, lv, formatFloat(c.values[lv]))
+	}
+}
+
+// GaugeFunc es un gauge cuyo valor se calcula al momento de exponerlo
+type GaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc crea y registra un gauge calculado
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{metricName: name, help: help, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.metricName }
+
+func (g *GaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.metricName, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.fn()))
+}
+
+// HistogramVec es un histograma con una etiqueta
+type HistogramVec struct {
+	metricName string
+	help       string
+	label      string
+	buckets    []float64
+	mu         sync.Mutex
+	series     map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec crea y registra un histograma con una etiqueta
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{metricName: name, help: help, label: label, buckets: buckets, series: make(map[string]*histogram)}
+	r.register(h)
+	return h
+}
+
+// Observe registra una observación para el valor de etiqueta dado
+func (h *HistogramVec) Observe(labelValue string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince registra el tiempo transcurrido desde start, en segundos
+func (h *HistogramVec) ObserveSince(labelValue string, start time.Time) {
+	h.Observe(labelValue, time.Since(start).Seconds())
+}
+
+func (h *HistogramVec) name() string { return h.metricName }
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+	for _, lv := range sortedKeys(h.series) {
+		s := h.series[lv]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=%q} %d\n", h.metricName, h.label, lv, formatFloat(bound), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", h.metricName, h.label, lv, s.count)
+		fmt.Fprintf(w, "%s_sum{%s=%q} %s\n", h.metricName, h.label, lv, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s=%q} %d\n", h.metricName, h.label, lv, s.count)
+	}
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	help = strings.NewReplacer("\\", `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegistryExposition prueba el formato de texto de Prometheus
+func TestRegistryExposition(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("test_updates_total", "Updates recibidos.", "type")
+	hist := r.NewHistogramVec("test_duration_seconds", "Duración.", "job", []float64{0.1, 1})
+	r.NewGaugeFunc("test_habits", "Hábitos.", func() float64 { return 3 })
+
+	counter.Inc("message")
+	counter.Inc("message")
+	counter.Inc("callback_query")
+	hist.Observe("morning", 0.05)
+	hist.Observe("morning", 0.5)
+
+	var out strings.Builder
+	r.Write(&out)
+	text := out.String()
+
+	expected := []string{
+		"# TYPE test_updates_total counter",
+		`test_updates_total{type="message"} 2`,
+		`test_updates_total{type="callback_query"} 1`,
+		"# TYPE test_habits gauge",
+		"test_habits 3",
+		"# TYPE test_duration_seconds histogram",
+		`test_duration_seconds_bucket{job="morning",le="0.1"} 1`,
+		`test_duration_seconds_bucket{job="morning",le="1"} 2`,
+		`test_duration_seconds_bucket{job="morning",le="+Inf"} 2`,
+		`test_duration_seconds_sum{job="morning"} 0.55`,
+		`test_duration_seconds_count{job="morning"} 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line %q in output:\n%s", line, text)
+		}
+	}
+}
+
+// TestRequireToken prueba que /metrics exija el token configurado
+func TestRequireToken(t *testing.T) {
+	handler := RequireToken("secreto", NewRegistry().Handler())
+
+	cases := []struct {
+		header string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer otro", http.StatusUnauthorized},
+		{"secreto", http.StatusUnauthorized},
+		{"Bearer secreto", http.StatusOK},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("Authorization %q: expected %d, got %d", c.header, c.status, rec.Code)
+		}
+	}
+}
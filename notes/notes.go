@@ -0,0 +1,254 @@
+// Package notes escribe una nota diaria en Markdown con los hábitos del día,
+// para bóvedas de Obsidian o Logseq, y lee los cambios de las casillas.
+package notes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"habittracker/habits"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Marcadores del bloque administrado por el bot. El resto de la nota es del usuario.
+const (
+	blockStart = "<!-- habittracker:start -->"
+	blockEnd   = "<!-- habittracker:end -->"
+)
+
+// DefaultFilename es la plantilla por defecto del nombre de archivo
+const DefaultFilename = "{{.Date}}.md"
+
+// checkboxLine reconoce una línea del bloque: la casilla, y el ID y el estado
+// escrito por última vez, que quedan en un comentario invisible al renderizar
+var checkboxLine = regexp.MustCompile(`^\s*[-*] \[([ xX])\] .*<!-- habit:(\d+)( done)? -->\s*$`)
+
+// FilenameData son los campos disponibles en la plantilla del nombre de archivo
+type FilenameData struct {
+	Date  string // YYYY-MM-DD
+	Year  string
+	Month string
+	Day   string
+}
+
+// Syncer escribe y lee las notas diarias de un directorio
+type Syncer struct {
+	habitManager *habits.HabitManager
+	dir          string
+	filename     *template.Template
+}
+
+// NewSyncer crea el sincronizador. filename es una plantilla de text/template
+// relativa a dir, por ejemplo "{{.Year}}/{{.Date}}.md".
+func NewSyncer(habitManager *habits.HabitManager, dir, filename string) (*Syncer, error) {
+	if filename == "" {
+		filename = DefaultFilename
+	}
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(filename)
+	if err != nil {
+		return nil, fmt.Errorf("invalid daily note filename template: %w", err)
+	}
+	return &Syncer{habitManager: habitManager, dir: dir, filename: tmpl}, nil
+}
+
+// Path devuelve la ruta de la nota de una fecha (YYYY-MM-DD)
+func (s *Syncer) Path(date string) (string, error) {
+	d, err := time.Parse(habits.DateFormat, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", date, err)
+	}
+
+	var buf bytes.Buffer
+	err = s.filename.Execute(&buf, FilenameData{
+		Date:  date,
+		Year:  d.Format("2006"),
+		Month: d.Format("01"),
+		Day:   d.Format("02"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Clean(buf.String())
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("daily note path %q escapes the notes directory", buf.String())
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Sync sincroniza los últimos days días hasta today: con readBack primero aplica
+// las casillas cambiadas a mano en las notas, y luego reescribe cada nota.
+// Devuelve la cantidad de logs actualizados desde las notas.
+func (s *Syncer) Sync(today time.Time, days int, readBack bool) (int, error) {
+	updated := 0
+	for i := days - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(habits.DateFormat)
+		if readBack {
+			n, err := s.ReadDay(date)
+			if err != nil {
+				return updated, err
+			}
+			updated += n
+		}
+		if err := s.WriteDay(date); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// WriteDay escribe el bloque de hábitos en la nota del día, creándola si no existe
+func (s *Syncer) WriteDay(date string) error {
+	path, err := s.Path(date)
+	if err != nil {
+		return err
+	}
+
+	block := s.render(date)
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content := "# " + date + "\n\n" + block
+	if len(existing) > 0 {
+		content = replaceBlock(string(existing), block)
+	}
+	if content == string(existing) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// ReadDay aplica a los logs las casillas que se cambiaron en la nota desde la
+// última escritura. Devuelve la cantidad de logs actualizados.
+func (s *Syncer) ReadDay(date string) (int, error) {
+	path, err := s.Path(date)
+	if err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	logs := make(map[int]habits.DailyLog)
+	for _, log := range s.habitManager.GetDailyLogs(date, date, 0) {
+		logs[log.HabitID] = log
+	}
+
+	updated := 0
+	for _, line := range strings.Split(extractBlock(string(data)), "\n") {
+		m := checkboxLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		checked := m[1] != " "
+		written := m[3] != ""
+		if checked == written {
+			continue // sin cambios en la nota: vale el estado del bot
+		}
+
+		id, _ := strconv.Atoi(m[2])
+		log, ok := logs[id]
+		if !ok {
+			log = habits.DailyLog{Date: date, HabitID: id}
+		}
+		if log.Completed == checked {
+			continue
+		}
+		log.Completed = checked
+		if err := s.habitManager.UpsertDailyLog(log); err != nil {
+			if errors.Is(err, habits.ErrNotFound) {
+				continue // hábito eliminado desde que se escribió la nota
+			}
+			return updated, fmt.Errorf("%s: %w", path, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// render arma el bloque de hábitos del día: los que corresponden ese día y los
+// que tienen registro
+func (s *Syncer) render(date string) string {
+	logs := make(map[int]habits.DailyLog)
+	for _, log := range s.habitManager.GetDailyLogs(date, date, 0) {
+		logs[log.HabitID] = log
+	}
+
+	var b strings.Builder
+	b.WriteString(blockStart + "\n")
+	b.WriteString("## Hábitos\n\n")
+
+	count := 0
+	for _, habit := range s.habitManager.GetHabits() {
+		log, logged := logs[habit.ID]
+		if !logged && (habit.Archived || !habit.DueOn(date)) {
+			continue
+		}
+		count++
+
+		box, state := " ", ""
+		if log.Completed {
+			box, state = "x", " done"
+		}
+		line := fmt.Sprintf("- [%s] %s", box, habit.Name)
+		if log.Planned {
+			line += " 📅"
+		}
+		if log.Value != 0 {
+			line += fmt.Sprintf(" — %g", log.Value)
+		}
+		if log.Notes != "" {
+			line += " — " + strings.ReplaceAll(log.Notes, "\n", " ")
+		}
+		fmt.Fprintf(&b, "%s <!-- habit:%d%s -->\n", line, habit.ID, state)
+	}
+	if count == 0 {
+		b.WriteString("_Sin hábitos para este día._\n")
+	}
+
+	b.WriteString(blockEnd + "\n")
+	return b.String()
+}
+
+// replaceBlock reemplaza el bloque administrado de la nota, o lo agrega al final
+func replaceBlock(content, block string) string {
+	start := strings.Index(content, blockStart)
+	end := strings.Index(content, blockEnd)
+	if start == -1 || end < start {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return content + "\n" + block
+	}
+
+	rest := content[end+len(blockEnd):]
+	rest = strings.TrimPrefix(rest, "\n")
+	return content[:start] + block + rest
+}
+
+// extractBlock devuelve el contenido del bloque administrado (vacío si no está)
+func extractBlock(content string) string {
+	start := strings.Index(content, blockStart)
+	end := strings.Index(content, blockEnd)
+	if start == -1 || end < start {
+		return ""
+	}
+	return content[start+len(blockStart) : end]
+}
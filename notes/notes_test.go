@@ -0,0 +1,91 @@
+package notes
+
+import (
+	"habittracker/habits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSync prueba la escritura de notas y la lectura de casillas cambiadas a mano
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	hm.Merge([]habits.Habit{{ID: 1, Name: "Leer", CreatedAt: created}, {ID: 2, Name: "Correr", CreatedAt: created}}, nil, false)
+	hm.UpsertDailyLog(habits.DailyLog{Date: "2024-03-05", HabitID: 1, Planned: true, Completed: true, Value: 20, Notes: "cap. 3"})
+
+	vault := filepath.Join(dir, "vault")
+	s, err := NewSyncer(hm, vault, "{{.Year}}/{{.Month}}/{{.Date}}.md")
+	if err != nil {
+		t.Fatalf("NewSyncer failed: %v", err)
+	}
+
+	today := time.Date(2024, 3, 5, 22, 0, 0, 0, time.Local)
+	if _, err := s.Sync(today, 2, true); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	path := filepath.Join(vault, "2024", "03", "2024-03-05.md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected note to be written: %v", err)
+	}
+	note := string(data)
+	for _, want := range []string{
+		"# 2024-03-05\n",
+		"- [x] Leer 📅 — 20 — cap. 3 <!-- habit:1 done -->\n",
+		"- [ ] Correr <!-- habit:2 -->\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("Expected note to contain %q, got:\n%s", want, note)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vault, "2024", "03", "2024-03-04.md")); err != nil {
+		t.Errorf("Expected the previous day's note: %v", err)
+	}
+
+	// El usuario marca Correr, agrega texto propio, y el bot marca Leer como no completado
+	note = strings.Replace(note, "- [ ] Correr", "- [x] Correr", 1) + "\nMis notas del día.\n"
+	os.WriteFile(path, []byte(note), 0644)
+	hm.UpsertDailyLog(habits.DailyLog{Date: "2024-03-05", HabitID: 1, Completed: false})
+
+	updated, err := s.Sync(today, 1, true)
+	if err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("Expected 1 log updated from the note, got %d", updated)
+	}
+
+	logs := hm.GetDailyLogs("2024-03-05", "2024-03-05", 0)
+	if len(logs) != 2 || logs[0].Completed || !logs[1].Completed {
+		t.Errorf("Expected Leer pending and Correr completed, got %+v", logs)
+	}
+
+	data, _ = os.ReadFile(path)
+	note = string(data)
+	if !strings.Contains(note, "- [x] Correr <!-- habit:2 done -->") || !strings.Contains(note, "- [ ] Leer") {
+		t.Errorf("Expected the note to reflect the merged state, got:\n%s", note)
+	}
+	if !strings.HasSuffix(note, "Mis notas del día.\n") {
+		t.Errorf("User content outside the block must be kept, got:\n%s", note)
+	}
+}
+
+// TestPathRejectsEscapes prueba que la plantilla no puede escribir fuera del directorio
+func TestPathRejectsEscapes(t *testing.T) {
+	s, err := NewSyncer(nil, "vault", "../{{.Date}}.md")
+	if err != nil {
+		t.Fatalf("NewSyncer failed: %v", err)
+	}
+	if _, err := s.Path("2024-03-05"); err == nil {
+		t.Error("Expected an error for a path outside the directory")
+	}
+}
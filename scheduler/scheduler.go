@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"fmt"
-	"log"
+	"habittracker/metrics"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -11,6 +13,8 @@ import (
 type Scheduler struct {
 	cron     *cron.Cron
 	timezone *time.Location
+	mu       sync.RWMutex
+	running  bool
 }
 
 // NewScheduler crea un nuevo scheduler
@@ -28,8 +32,18 @@ func NewScheduler(timezone string) (*Scheduler, error) {
 	}, nil
 }
 
+// Location devuelve la zona horaria del scheduler
+func (s *Scheduler) Location() *time.Location {
+	return s.timezone
+}
+
 // ScheduleDailyReminder programa un recordatorio diario
 func (s *Scheduler) ScheduleDailyReminder(timeStr string, callback func()) error {
+	return s.ScheduleNamedReminder("daily_reminder", timeStr, callback)
+}
+
+// ScheduleNamedReminder programa un recordatorio diario identificado por nombre en las métricas
+func (s *Scheduler) ScheduleNamedReminder(name, timeStr string, callback func()) error {
 	// Parsear la hora (formato HH:MM)
 	t, err := time.Parse("15:04", timeStr)
 	if err != nil {
@@ -39,11 +53,33 @@ func (s *Scheduler) ScheduleDailyReminder(timeStr string, callback func()) error
 	// Crear expresión cron (minuto hora * * *)
 	cronExpr := fmt.Sprintf("%d %d * * *", t.Minute(), t.Hour())
 
-	log.Printf("Scheduling daily reminder at %s (cron: %s) in timezone %s", timeStr, cronExpr, s.timezone)
+	slog.Info("Scheduling job", "job", name, "time", timeStr, "cron", cronExpr, "timezone", s.timezone.String())
 
 	_, err = s.cron.AddFunc(cronExpr, func() {
-		log.Println("Executing daily reminder...")
+		slog.Info("Executing scheduled job", "job", name)
+		start := time.Now()
+		callback()
+		metrics.JobRunsTotal.Inc(name)
+		metrics.JobDuration.ObserveSince(name, start)
+	})
+
+	return err
+}
+
+// ScheduleInterval programa una tarea que se repite cada interval, identificada por nombre en las métricas
+func (s *Scheduler) ScheduleInterval(name string, interval time.Duration, callback func()) error {
+	if interval < time.Minute {
+		return fmt.Errorf("interval %s for job %s is shorter than a minute", interval, name)
+	}
+
+	slog.Info("Scheduling job", "job", name, "interval", interval.String())
+
+	_, err := s.cron.AddFunc("@every "+interval.String(), func() {
+		slog.Debug("Executing scheduled job", "job", name)
+		start := time.Now()
 		callback()
+		metrics.JobRunsTotal.Inc(name)
+		metrics.JobDuration.ObserveSince(name, start)
 	})
 
 	return err
@@ -51,12 +87,35 @@ func (s *Scheduler) ScheduleDailyReminder(timeStr string, callback func()) error
 
 // Start inicia el scheduler
 func (s *Scheduler) Start() {
-	log.Println("Scheduler started")
+	slog.Info("Scheduler started")
 	s.cron.Start()
+
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
 }
 
 // Stop detiene el scheduler
 func (s *Scheduler) Stop() {
-	log.Println("Scheduler stopped")
+	slog.Info("Scheduler stopped")
 	s.cron.Stop()
+
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+// Running indica si el scheduler está en ejecución
+func (s *Scheduler) Running() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.running
+}
+
+// CheckRunning devuelve un error si el scheduler no está en ejecución (para /readyz)
+func (s *Scheduler) CheckRunning() error {
+	if !s.Running() {
+		return fmt.Errorf("scheduler not running")
+	}
+	return nil
 }
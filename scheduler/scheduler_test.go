@@ -203,3 +203,40 @@ func TestInvalidTimezone(t *testing.T) {
 		t.Error("Expected error for invalid timezone, got nil")
 	}
 }
+
+// TestSchedulerRunningState prueba que el estado de ejecución se refleja en CheckRunning
+func TestSchedulerRunningState(t *testing.T) {
+	sched, err := NewScheduler("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	if err := sched.CheckRunning(); err == nil {
+		t.Error("Expected error before Start, got nil")
+	}
+
+	sched.Start()
+	if err := sched.CheckRunning(); err != nil {
+		t.Errorf("Expected scheduler to be running, got %v", err)
+	}
+
+	sched.Stop()
+	if sched.Running() {
+		t.Error("Expected scheduler to be stopped")
+	}
+}
+
+// TestScheduleInterval prueba la validación del intervalo de las tareas periódicas
+func TestScheduleInterval(t *testing.T) {
+	sched, err := NewScheduler("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+
+	if err := sched.ScheduleInterval("too_fast", 10*time.Second, func() {}); err == nil {
+		t.Error("Expected error for an interval shorter than a minute")
+	}
+	if err := sched.ScheduleInterval("sync", 15*time.Minute, func() {}); err != nil {
+		t.Errorf("Failed to schedule interval job: %v", err)
+	}
+}
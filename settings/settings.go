@@ -0,0 +1,84 @@
+// Package settings guarda las preferencias de cada usuario de Telegram
+package settings
+
+import (
+	"encoding/json"
+	"habittracker/storage"
+	"os"
+	"sync"
+)
+
+// User son las preferencias de un usuario
+type User struct {
+	Language         string `json:"language,omitempty"`          // Elegido con /language
+	DetectedLanguage string `json:"detected_language,omitempty"` // Idioma de Telegram, para los mensajes programados
+	Name             string `json:"name,omitempty"`              // Nombre de Telegram, para las plantillas de mensajes
+	QuietHours       string `json:"quiet_hours,omitempty"`       // Horario de silencio (HH:MM-HH:MM), elegido con /quiet
+	DoNotDisturb     string `json:"do_not_disturb,omitempty"`    // Día (YYYY-MM-DD) sin mensajes programados, elegido con /dnd
+}
+
+// Store guarda las preferencias en un archivo JSON, por ID de usuario
+type Store struct {
+	users map[int64]User
+	file  string
+	mu    sync.RWMutex
+}
+
+// NewStore crea el almacén y carga las preferencias existentes
+func NewStore(file string) (*Store, error) {
+	s := &Store{
+		users: make(map[int64]User),
+		file:  file,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get devuelve las preferencias de un usuario (vacías si no tiene)
+func (s *Store) Get(userID int64) User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[userID]
+}
+
+// Update modifica las preferencias de un usuario. Solo escribe el archivo si cambiaron.
+func (s *Store) Update(userID int64, fn func(u *User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.users[userID]
+	fn(&u)
+	if u == s.users[userID] {
+		return nil
+	}
+	s.users[userID] = u
+	return s.save()
+}
+
+// load carga las preferencias desde el archivo
+func (s *Store) load() error {
+	data, err := storage.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, &s.users)
+}
+
+// save guarda las preferencias en el archivo
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(s.file, data, 0600)
+}
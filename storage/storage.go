@@ -0,0 +1,248 @@
+// Package storage lee y escribe los archivos de datos, cifrándolos con AES-GCM
+// cuando hay una clave configurada. Los archivos en texto plano se siguen
+// leyendo, así que activar el cifrado no requiere migrar los datos antes.
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Formato de un archivo cifrado: magic | ID de clave | nonce | texto cifrado
+var magic = []byte("HTENC1")
+
+const (
+	keySize   = 32 // AES-256
+	keyIDSize = 8
+	nonceSize = 12
+)
+
+var (
+	// ErrNoKey se devuelve al leer un archivo cifrado sin la clave con que se cifró
+	ErrNoKey = errors.New("encryption key not available")
+	// ErrDecrypt se devuelve cuando un archivo cifrado está dañado o fue modificado
+	ErrDecrypt = errors.New("decryption failed")
+)
+
+// Keyring es el conjunto de claves: la primaria cifra y todas descifran, lo que
+// permite rotar la clave sin dejar de leer los archivos cifrados con las anteriores
+type Keyring struct {
+	primary *key
+	keys    map[string]*key
+}
+
+type key struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+var (
+	mu      sync.RWMutex
+	current *Keyring
+)
+
+// NewKeyring crea un keyring con la clave primaria y las anteriores (solo para leer)
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*key)}
+	for i, raw := range append([][]byte{primary}, previous...) {
+		if len(raw) != keySize {
+			return nil, fmt.Errorf("invalid encryption key: expected %d bytes, got %d", keySize, len(raw))
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(raw)
+		entry := &key{id: sum[:keyIDSize], aead: aead}
+		if i == 0 {
+			k.primary = entry
+		}
+		k.keys[string(entry.id)] = entry
+	}
+	return k, nil
+}
+
+// ParseKey decodifica una clave en base64 o hexadecimal
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if raw, err := hex.DecodeString(s); err == nil && len(raw) == keySize {
+		return raw, nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil && len(raw) == keySize {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("invalid encryption key: expected %d bytes in base64 or hex", keySize)
+}
+
+// GenerateKey genera una clave nueva en base64
+func GenerateKey() (string, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// KeyringFromEnv arma el keyring desde ENCRYPTION_KEY o ENCRYPTION_KEY_FILE (clave
+// primaria) y ENCRYPTION_OLD_KEYS (claves anteriores separadas por comas).
+// Devuelve nil si no hay clave configurada.
+func KeyringFromEnv() (*Keyring, error) {
+	primary := os.Getenv("ENCRYPTION_KEY")
+	if file := os.Getenv("ENCRYPTION_KEY_FILE"); file != "" {
+		if primary != "" {
+			return nil, fmt.Errorf("set only one of ENCRYPTION_KEY and ENCRYPTION_KEY_FILE")
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading ENCRYPTION_KEY_FILE: %w", err)
+		}
+		primary = string(data)
+	}
+
+	oldKeys := os.Getenv("ENCRYPTION_OLD_KEYS")
+	if primary == "" {
+		if oldKeys != "" {
+			return nil, fmt.Errorf("ENCRYPTION_OLD_KEYS requires ENCRYPTION_KEY")
+		}
+		return nil, nil
+	}
+
+	raw, err := ParseKey(primary)
+	if err != nil {
+		return nil, err
+	}
+	var previous [][]byte
+	for _, s := range strings.Split(oldKeys, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		old, err := ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_OLD_KEYS: %w", err)
+		}
+		previous = append(previous, old)
+	}
+	return NewKeyring(raw, previous...)
+}
+
+// SetKeyring configura el keyring usado por ReadFile y WriteFile (nil: sin cifrado)
+func SetKeyring(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = k
+}
+
+// Enabled indica si los archivos se escriben cifrados
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+// Encrypted indica si el contenido de un archivo está cifrado
+func Encrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// ReadFile lee un archivo de datos, descifrándolo si está cifrado
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !Encrypted(data) {
+		return data, err
+	}
+
+	mu.RLock()
+	k := current
+	mu.RUnlock()
+	if k == nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", path, ErrNoKey)
+	}
+
+	plaintext, err := k.Decrypt(filepath.Base(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plaintext, nil
+}
+
+// WriteFile escribe un archivo de datos de forma atómica, cifrado si hay keyring
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	mu.RLock()
+	k := current
+	mu.RUnlock()
+
+	if k != nil {
+		var err error
+		if data, err = k.Encrypt(filepath.Base(path), data); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := errors.Join(tmp.Chmod(perm), writeAll(tmp, data), tmp.Close()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func writeAll(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// Encrypt cifra el contenido de un archivo con la clave primaria. El nombre del
+// archivo se autentica para que no se puedan intercambiar archivos cifrados.
+func (k *Keyring) Encrypt(name string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := append(append(append([]byte{}, magic...), k.primary.id...), nonce...)
+	return k.primary.aead.Seal(header, nonce, plaintext, additionalData(name, k.primary.id)), nil
+}
+
+// Decrypt descifra el contenido de un archivo con la clave con que fue cifrado
+func (k *Keyring) Decrypt(name string, data []byte) ([]byte, error) {
+	headerSize := len(magic) + keyIDSize + nonceSize
+	if !Encrypted(data) || len(data) < headerSize {
+		return nil, ErrDecrypt
+	}
+
+	id := data[len(magic) : len(magic)+keyIDSize]
+	entry, ok := k.keys[string(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w (key id %x)", ErrNoKey, id)
+	}
+
+	nonce := data[len(magic)+keyIDSize : headerSize]
+	plaintext, err := entry.aead.Open(nil, nonce, data[headerSize:], additionalData(name, id))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func additionalData(name string, id []byte) []byte {
+	return append(append(append([]byte{}, magic...), id...), name...)
+}
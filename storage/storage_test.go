@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+// TestReadWriteFile prueba que los archivos se cifran al escribir y se descifran al leer
+func TestReadWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "habits.json")
+	plaintext := []byte(`[{"id":1,"name":"Leer"}]`)
+
+	// Sin keyring se escribe y se lee texto plano
+	SetKeyring(nil)
+	if err := WriteFile(path, plaintext, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !bytes.Equal(raw, plaintext) {
+		t.Fatalf("Expected plaintext on disk, got %q", raw)
+	}
+
+	keyring, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	SetKeyring(keyring)
+	defer SetKeyring(nil)
+
+	// Los archivos en texto plano se siguen leyendo con el cifrado activo
+	data, err := ReadFile(path)
+	if err != nil || !bytes.Equal(data, plaintext) {
+		t.Fatalf("Expected plaintext passthrough, got %q, %v", data, err)
+	}
+
+	if err := WriteFile(path, plaintext, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	raw, _ = os.ReadFile(path)
+	if !Encrypted(raw) || bytes.Contains(raw, []byte("Leer")) {
+		t.Fatalf("Expected encrypted file on disk, got %q", raw)
+	}
+
+	data, err = ReadFile(path)
+	if err != nil || !bytes.Equal(data, plaintext) {
+		t.Fatalf("Expected decrypted content, got %q, %v", data, err)
+	}
+
+	SetKeyring(nil)
+	if _, err := ReadFile(path); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey without keyring, got %v", err)
+	}
+}
+
+// TestKeyRotation prueba que las claves anteriores descifran y la primaria cifra
+func TestKeyRotation(t *testing.T) {
+	oldKeyring, _ := NewKeyring(testKey(1))
+	encrypted, err := oldKeyring.Encrypt("habits.json", []byte("datos"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	data, err := rotated.Decrypt("habits.json", encrypted)
+	if err != nil || string(data) != "datos" {
+		t.Fatalf("Expected old key to decrypt, got %q, %v", data, err)
+	}
+
+	reencrypted, _ := rotated.Encrypt("habits.json", data)
+	if _, err := oldKeyring.Decrypt("habits.json", reencrypted); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected re-encrypted file to use the new key, got %v", err)
+	}
+
+	newOnly, _ := NewKeyring(testKey(2))
+	if _, err := newOnly.Decrypt("habits.json", encrypted); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey once the old key is dropped, got %v", err)
+	}
+}
+
+// TestDecryptTampered prueba que se rechazan los archivos modificados o intercambiados
+func TestDecryptTampered(t *testing.T) {
+	keyring, _ := NewKeyring(testKey(1))
+	encrypted, _ := keyring.Encrypt("habits.json", []byte("datos"))
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := keyring.Decrypt("habits.json", tampered); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for tampered data, got %v", err)
+	}
+
+	if _, err := keyring.Decrypt("daily_logs.json", encrypted); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a file with a different name, got %v", err)
+	}
+
+	if _, err := keyring.Decrypt("habits.json", encrypted[:len(magic)+2]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for truncated data, got %v", err)
+	}
+}
+
+// TestParseKey prueba las claves en base64 y hexadecimal
+func TestParseKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if _, err := ParseKey(key + "\n"); err != nil {
+		t.Errorf("Expected generated key to parse, got %v", err)
+	}
+	if _, err := ParseKey("00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"); err != nil {
+		t.Errorf("Expected hex key to parse, got %v", err)
+	}
+	if _, err := ParseKey("corta"); err == nil {
+		t.Error("Expected error for short key")
+	}
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"embed"
+	"errors"
+	"habittracker/auth"
+	"habittracker/habits"
+	"habittracker/logging"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// sessionCookie es el nombre de la cookie de sesión del dashboard
+const sessionCookie = "habittracker_session"
+
+// Semanas mostradas en el heatmap y en la tendencia semanal
+const (
+	heatmapWeeks = 26
+	trendWeeks   = 8
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static/*
+var staticFS embed.FS
+
+// loginView son los datos de la plantilla login_error.html
+type loginView struct {
+	LoggedOut bool
+}
+
+// Dashboard sirve el dashboard HTML con el progreso de los hábitos
+type Dashboard struct {
+	habitManager *habits.HabitManager
+	sessions     *auth.SessionStore
+	users        *auth.UserStore
+	loc          *time.Location
+	templates    *template.Template
+	mux          *http.ServeMux
+}
+
+// NewDashboard crea el dashboard y parsea las plantillas embebidas. Los días
+// (hoy, el heatmap y la tendencia) se cuentan en la zona horaria loc.
+func NewDashboard(habitManager *habits.HabitManager, sessions *auth.SessionStore, loc *time.Location) (*Dashboard, error) {
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"percent": func(rate float64) int { return int(rate*100 + 0.5) },
+	}).ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	static, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dashboard{
+		habitManager: habitManager,
+		sessions:     sessions,
+		loc:          loc,
+		templates:    tmpl,
+		mux:          http.NewServeMux(),
+	}
+
+	d.mux.HandleFunc("GET /dashboard", d.requireSession(d.handleDashboard))
+	d.mux.HandleFunc("GET /dashboard/login", d.handleLogin)
+	d.mux.HandleFunc("POST /dashboard/logout", d.handleLogout)
+	d.mux.Handle("GET /dashboard/static/", http.StripPrefix("/dashboard/static/", http.FileServerFS(static)))
+
+	return d, nil
+}
+
+// SetUsers limita el dashboard a los dueños de los hábitos (administradores y
+// lista de la configuración): rechaza las sesiones de los aprobados con
+// /approve y de los bloqueados
+func (d *Dashboard) SetUsers(users *auth.UserStore) {
+	d.users = users
+}
+
+// Handler devuelve el handler HTTP del dashboard (montar en /dashboard)
+func (d *Dashboard) Handler() http.Handler {
+	return d.mux
+}
+
+// handleLogin canjea el código de un solo uso enviado por /dashboard en el bot
+func (d *Dashboard) handleLogin(w http.ResponseWriter, r *http.Request) {
+	session, userID, err := d.sessions.Redeem(r.URL.Query().Get("code"))
+	if err != nil {
+		d.render(w, r, http.StatusUnauthorized, "login_error.html", loginView{})
+		return
+	}
+
+	slog.Info("Dashboard login", "user_id", userID)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session,
+		Path:     "/dashboard",
+		MaxAge:   int(d.sessions.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+func (d *Dashboard) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		d.sessions.End(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/dashboard",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	d.render(w, r, http.StatusOK, "login_error.html", loginView{LoggedOut: true})
+}
+
+func (d *Dashboard) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().In(d.loc)
+	d.render(w, r, http.StatusOK, "dashboard.html", d.buildView(now))
+}
+
+// requireSession exige una cookie de sesión válida
+func (d *Dashboard) requireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err == nil {
+			var userID int64
+			userID, err = d.sessions.Validate(cookie.Value)
+			if err == nil && d.users != nil && !d.users.OwnsHabits(userID) {
+				d.sessions.End(cookie.Value)
+				err = auth.ErrInvalidSession
+			}
+			if err == nil {
+				logger := slog.Default().With("user_id", userID, "path", r.URL.Path)
+				next(w, r.WithContext(logging.WithLogger(r.Context(), logger)))
+				return
+			}
+		}
+
+		if errors.Is(err, http.ErrNoCookie) || errors.Is(err, auth.ErrInvalidSession) {
+			d.render(w, r, http.StatusUnauthorized, "login_error.html", loginView{})
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func (d *Dashboard) render(w http.ResponseWriter, r *http.Request, status int, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := d.templates.ExecuteTemplate(w, name, data); err != nil {
+		logging.FromContext(r.Context()).Error("Error rendering dashboard template", "template", name, "error", err)
+	}
+}
+
+// isSecure indica si la petición llegó por HTTPS (directo o detrás de un proxy)
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"habittracker/auth"
+	"habittracker/habits"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestDashboard(t *testing.T) (*Dashboard, *habits.HabitManager, *auth.SessionStore) {
+	t.Helper()
+	dir := t.TempDir()
+
+	hm := habits.NewHabitManager(
+		filepath.Join(dir, "habits.json"),
+		filepath.Join(dir, "responses.json"),
+		filepath.Join(dir, "daily_logs.json"),
+	)
+	sessions := auth.NewSessionStore()
+
+	d, err := NewDashboard(hm, sessions, time.Local)
+	if err != nil {
+		t.Fatalf("Failed to create dashboard: %v", err)
+	}
+	return d, hm, sessions
+}
+
+// TestDashboardLoginFlow prueba el canje del enlace de un solo uso y el acceso con cookie
+func TestDashboardLoginFlow(t *testing.T) {
+	d, hm, sessions := newTestDashboard(t)
+	h := d.Handler()
+
+	habit, _ := hm.AddHabit("Leer <script>", "")
+	today := time.Now().Format(habits.DateFormat)
+	hm.UpsertDailyLog(habits.DailyLog{Date: today, HabitID: habit.ID, Planned: true, Completed: true})
+
+	// Sin sesión
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without session, got %d", rec.Code)
+	}
+
+	code, _ := sessions.IssueLoginCode(42)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard/login?code="+code, nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("Expected redirect after login, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("Expected an HttpOnly session cookie, got %+v", cookies)
+	}
+
+	// El código no se puede reutilizar
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard/login?code="+code, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 when reusing login code, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 with session, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "Leer &lt;script&gt;") {
+		t.Error("Expected escaped habit name in dashboard")
+	}
+	if !strings.Contains(body, `class="cell done"`) {
+		t.Error("Expected a completed cell in the heatmap")
+	}
+}
+
+// login canjea un código de acceso y devuelve la cookie de sesión
+func login(t *testing.T, h http.Handler, sessions *auth.SessionStore, userID int64) *http.Cookie {
+	t.Helper()
+	code, _ := sessions.IssueLoginCode(userID)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard/login?code="+code, nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected a session cookie, got %+v", cookies)
+	}
+	return cookies[0]
+}
+
+// TestDashboardUsesConfiguredZone prueba que el día de hoy se cuenta en la
+// zona horaria configurada y no en la del sistema
+func TestDashboardUsesConfiguredZone(t *testing.T) {
+	for _, loc := range []*time.Location{time.FixedZone("UTC+14", 14*3600), time.FixedZone("UTC-12", -12*3600)} {
+		d, _, sessions := newTestDashboard(t)
+		d.loc = loc
+		h := d.Handler()
+
+		req := httptest.NewRequest("GET", "/dashboard", nil)
+		req.AddCookie(login(t, h, sessions, 42))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		today := time.Now().In(loc).Format(habits.DateFormat)
+		if !strings.Contains(rec.Body.String(), `<span class="today">`+today+`</span>`) {
+			t.Errorf("%s: expected today to be %s", loc, today)
+		}
+	}
+}
+
+// TestDashboardOwnersOnly prueba que los usuarios aprobados con /approve no
+// ven los hábitos
+func TestDashboardOwnersOnly(t *testing.T) {
+	d, _, sessions := newTestDashboard(t)
+	users, err := auth.NewUserStore(filepath.Join(t.TempDir(), "users.json"), []int64{7}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create user store: %v", err)
+	}
+	users.Request(auth.User{ID: 42})
+	users.Approve(42)
+	d.SetUsers(users)
+	h := d.Handler()
+
+	for userID, status := range map[int64]int{7: http.StatusOK, 42: http.StatusUnauthorized} {
+		req := httptest.NewRequest("GET", "/dashboard", nil)
+		req.AddCookie(login(t, h, sessions, userID))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("User %d: expected %d, got %d", userID, status, rec.Code)
+		}
+	}
+}
+
+// TestDashboardStaticAssets prueba que los assets embebidos se sirven
+func TestDashboardStaticAssets(t *testing.T) {
+	d, _, _ := newTestDashboard(t)
+
+	rec := httptest.NewRecorder()
+	d.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard/static/style.css", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), ".heatmap") {
+		t.Errorf("Expected embedded stylesheet, got %d", rec.Code)
+	}
+}
+
+// TestWeekStart prueba el cálculo del lunes de la semana
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	if got := weekStart(sunday); got.Weekday() != time.Monday || got.Day() != 4 {
+		t.Errorf("Expected Monday 4, got %v", got)
+	}
+}
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"fmt"
+	"habittracker/habits"
+	"time"
+)
+
+// dashboardView son los datos de la plantilla dashboard.html
+type dashboardView struct {
+	Today   string
+	Habits  []habitView
+	Weekday []string
+}
+
+// habitView es el resumen de un hábito en el dashboard
+type habitView struct {
+	Habit habits.Habit
+	Stats habits.HabitStats
+	Weeks [][]dayCell
+	Trend []trendBar
+}
+
+// dayCell es una celda del heatmap
+type dayCell struct {
+	Date  string
+	Class string
+	Title string
+}
+
+// trendBar es una barra de la tendencia semanal
+type trendBar struct {
+	Label     string
+	Completed int
+	Percent   int
+}
+
+// buildView arma los datos del dashboard para el día now
+func (d *Dashboard) buildView(now time.Time) dashboardView {
+	today := dateOnly(now)
+	start := weekStart(today).AddDate(0, 0, -7*(heatmapWeeks-1))
+	from := start.Format(habits.DateFormat)
+	to := today.Format(habits.DateFormat)
+
+	view := dashboardView{
+		Today:   to,
+		Weekday: []string{"L", "M", "M", "J", "V", "S", "D"},
+	}
+
+	for _, habit := range d.habitManager.GetActiveHabits() {
+		logs := d.habitManager.GetDailyLogs(from, to, habit.ID)
+		byDate := make(map[string]habits.DailyLog, len(logs))
+		for _, log := range logs {
+			byDate[log.Date] = log
+		}
+
+		hv := habitView{Habit: habit}
+		if stats, err := d.habitManager.GetStats(habit.ID, from, to); err == nil {
+			hv.Stats = *stats
+		}
+		hv.Weeks = heatmap(byDate, start, today, dateOnly(habit.CreatedAt.In(d.loc)))
+		hv.Trend = weeklyTrend(byDate, today)
+
+		view.Habits = append(view.Habits, hv)
+	}
+
+	return view
+}
+
+// heatmap arma una columna por semana (lunes a domingo) desde start hasta today
+func heatmap(byDate map[string]habits.DailyLog, start, today, created time.Time) [][]dayCell {
+	var weeks [][]dayCell
+	for ws := start; !ws.After(today); ws = ws.AddDate(0, 0, 7) {
+		week := make([]dayCell, 7)
+		for i := range week {
+			day := ws.AddDate(0, 0, i)
+			date := day.Format(habits.DateFormat)
+			log, ok := byDate[date]
+
+			cell := dayCell{Date: date, Class: "empty", Title: date}
+			switch {
+			case day.After(today):
+				cell.Class = "future"
+			case ok && log.Completed:
+				cell.Class = "done"
+				cell.Title = date + ": completado"
+			case ok && log.Planned:
+				cell.Class = "missed"
+				cell.Title = date + ": planeado, no completado"
+			case day.Before(created):
+				cell.Class = "before"
+			}
+			week[i] = cell
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// weeklyTrend cuenta los días completados en cada una de las últimas semanas
+func weeklyTrend(byDate map[string]habits.DailyLog, today time.Time) []trendBar {
+	current := weekStart(today)
+	bars := make([]trendBar, 0, trendWeeks)
+
+	for w := trendWeeks - 1; w >= 0; w-- {
+		ws := current.AddDate(0, 0, -7*w)
+		completed := 0
+		for i := 0; i < 7; i++ {
+			if byDate[ws.AddDate(0, 0, i).Format(habits.DateFormat)].Completed {
+				completed++
+			}
+		}
+		bars = append(bars, trendBar{
+			Label:     fmt.Sprintf("%02d/%02d", ws.Day(), ws.Month()),
+			Completed: completed,
+			Percent:   completed * 100 / 7,
+		})
+	}
+	return bars
+}
+
+// weekStart devuelve el lunes de la semana de t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// dateOnly descarta la hora, conservando la fecha local
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}